@@ -0,0 +1,116 @@
+package goose
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// The SQL callback files, when found in the migrations directory they are run at fixed points of the
+// commands that apply migrations. The Up section of a callback is run when migrating up, and the
+// Down section when migrating down.
+const (
+	// CallbackBeforeMigrate is run once before the first migration of a command
+	CallbackBeforeMigrate = "beforeMigrate.sql"
+	// CallbackAfterMigrate is run once after all the migrations of a command have been applied
+	CallbackAfterMigrate = "afterMigrate.sql"
+	// CallbackBeforeEachMigrate is run before each migration
+	CallbackBeforeEachMigrate = "beforeEachMigrate.sql"
+	// CallbackAfterEachMigrate is run after each migration
+	CallbackAfterEachMigrate = "afterEachMigrate.sql"
+)
+
+var sqlCallbackNames = []string{
+	CallbackBeforeMigrate,
+	CallbackAfterMigrate,
+	CallbackBeforeEachMigrate,
+	CallbackAfterEachMigrate,
+}
+
+// SQLCallbacksInTransaction makes the beforeEachMigrate.sql and afterEachMigrate.sql callbacks run in the
+// migration's transaction, instead of before the transaction is started and after it is committed.
+func SQLCallbacksInTransaction(b bool) func(p *Provider) {
+	return func(p *Provider) {
+		p.sqlCallbacksInTx = b
+	}
+}
+
+// sqlCallbacks is the content of the SQL callback files found, by file name
+type sqlCallbacks map[string][]byte
+
+// isSQLCallback returns if the file is one of the SQL callback files, and not a migration
+func isSQLCallback(name string) bool {
+	base := path.Base(name)
+	for _, cb := range sqlCallbackNames {
+		if base == cb {
+			return true
+		}
+	}
+	return false
+}
+
+// collectSQLCallbacks reads the SQL callback files in the directory
+func (p *Provider) collectSQLCallbacks(fsys fs.FS, dir string) (sqlCallbacks, error) {
+	callbacks := make(sqlCallbacks)
+	for _, name := range sqlCallbackNames {
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SQL callback %s: %w", name, err)
+		}
+		callbacks[name] = content
+	}
+	return callbacks, nil
+}
+
+// runSQLCallback runs the named callback, if it exists, in the given direction. The statements are
+// run in tx if it is not nil, otherwise in their own transaction unless the callback is annotated
+// with NO TRANSACTION or the dialect does not support transactions.
+func (p *Provider) runSQLCallback(name string, callbacks sqlCallbacks, direction bool, db *sql.DB, tx *sql.Tx) error {
+	content, ok := callbacks[name]
+	if !ok {
+		return nil
+	}
+	hookErr := func(err error) error {
+		return ErrHook{Hook: name, ErrUnwrap: ErrUnwrap{err}}
+	}
+	statements, useTx, err := parseSQLMigration(p, bytes.NewReader(content), direction)
+	if err != nil {
+		return hookErr(err)
+	}
+	if len(statements) == 0 {
+		return nil
+	}
+
+	exec := db.Exec
+	var own *sql.Tx
+	switch {
+	case tx != nil:
+		exec = tx.Exec
+	case useTx && p.Capabilities().Transactions:
+		if own, err = db.Begin(); err != nil {
+			return hookErr(fmt.Errorf("failed to begin transaction: %w", err))
+		}
+		exec = own.Exec
+	}
+	for _, query := range statements {
+		p.verboseInfo("Executing callback statement: %s", clearStatement(query))
+		if _, err := p.execQuery(exec, query); err != nil {
+			if own != nil {
+				_ = own.Rollback()
+			}
+			return hookErr(fmt.Errorf("failed to execute SQL query %q: %w", clearStatement(query), err))
+		}
+	}
+	if own != nil {
+		if err := own.Commit(); err != nil {
+			return hookErr(fmt.Errorf("failed to commit transaction: %w", err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSQLCallbacks(t *testing.T) {
+	t.Parallel()
+
+	callback := func(name string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(`-- +goose Up
+CREATE TABLE IF NOT EXISTS cb_log (name TEXT);
+INSERT INTO cb_log (name) VALUES ('` + name + ` up');
+-- +goose Down
+INSERT INTO cb_log (name) VALUES ('` + name + ` down');
+`)}
+	}
+	newFS := func() fstest.MapFS {
+		return fstest.MapFS{
+			"migrations/00001_a.sql":                  {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+			"migrations/00002_b.sql":                  {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+			"migrations/" + CallbackBeforeMigrate:     callback("beforeMigrate"),
+			"migrations/" + CallbackAfterMigrate:      callback("afterMigrate"),
+			"migrations/" + CallbackBeforeEachMigrate: callback("beforeEachMigrate"),
+			"migrations/" + CallbackAfterEachMigrate:  callback("afterEachMigrate"),
+		}
+	}
+	logged := func(t *testing.T, db *sql.DB) []string {
+		rows, err := db.Query("SELECT name FROM cb_log ORDER BY rowid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+
+	for _, inTx := range []bool{false, true} {
+		inTx := inTx
+		name := "outside transaction"
+		if inTx {
+			name = "in transaction"
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			p, db := newSQLiteProvider(t, newFS(), SQLCallbacksInTransaction(inTx))
+			if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Down(db, "migrations", WithNoOutput()); err != nil {
+				t.Fatal(err)
+			}
+			expected := []string{
+				"beforeMigrate up",
+				"beforeEachMigrate up", "afterEachMigrate up",
+				"beforeEachMigrate up", "afterEachMigrate up",
+				"afterMigrate up",
+				"beforeMigrate down",
+				"beforeEachMigrate down", "afterEachMigrate down",
+				"afterMigrate down",
+			}
+			if got := logged(t, db); !equalStrings(got, expected) {
+				t.Errorf("callbacks, got %q expected %q", got, expected)
+			}
+		})
+	}
+
+	t.Run("failing callback in transaction rolls back", func(t *testing.T) {
+		t.Parallel()
+		fsys := newFS()
+		fsys["migrations/"+CallbackAfterEachMigrate] = &fstest.MapFile{Data: []byte("-- +goose Up\nINSERT INTO missing (id) VALUES (1);\n")}
+		p, db := newSQLiteProvider(t, fsys, SQLCallbacksInTransaction(true))
+		err := p.Up(db, "migrations", WithNoOutput())
+		var hookErr ErrHook
+		if !errors.As(err, &hookErr) || hookErr.Hook != CallbackAfterEachMigrate {
+			t.Fatalf("error, got %v expected an %s ErrHook", err, CallbackAfterEachMigrate)
+		}
+		var merr MigrationError
+		if !errors.As(err, &merr) || merr.Version != 1 || !merr.RolledBack {
+			t.Errorf("migration error, got %+v expected version 1 rolled back", merr)
+		}
+		if _, err := db.Exec("SELECT * FROM a"); err == nil {
+			t.Errorf("table a, expected the migration to be rolled back")
+		}
+	})
+
+	t.Run("failing callback after commit", func(t *testing.T) {
+		t.Parallel()
+		fsys := newFS()
+		fsys["migrations/"+CallbackAfterEachMigrate] = &fstest.MapFile{Data: []byte("-- +goose Up\nINSERT INTO missing (id) VALUES (1);\n")}
+		p, db := newSQLiteProvider(t, fsys)
+		events := make(chan Eventer, 20)
+		err := p.Up(db, "migrations", WithNoOutput(), WithEvents(events, false))
+		var hookErr ErrHook
+		if !errors.As(err, &hookErr) || hookErr.Hook != CallbackAfterEachMigrate || !hookErr.Committed || hookErr.Source != "migrations/00001_a.sql" {
+			t.Fatalf("error, got %v expected a committed %s ErrHook", err, CallbackAfterEachMigrate)
+		}
+		if errors.As(err, &MigrationError{}) {
+			t.Errorf("error, got %v expected the migration not to be reported as failed", err)
+		}
+		applied := false
+		for e := range events {
+			switch e := e.(type) {
+			case VersionFailedEvent:
+				t.Errorf("events, got %+v expected no failed migration", e)
+			case VersionApplyEvent:
+				applied = applied || e.Applied && e.To == 1
+			}
+		}
+		if !applied {
+			t.Errorf("events, expected version 1 to be applied")
+		}
+		if version, err := p.GetDBVersion(db); err != nil || version != 1 {
+			t.Errorf("version, got %d, %v expected 1", version, err)
+		}
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		t.Parallel()
+		fsys := newFS()
+		fsys["migrations/"+CallbackAfterMigrate] = &fstest.MapFile{Data: []byte("-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n")}
+		fsys["migrations/"+CallbackBeforeMigrate] = &fstest.MapFile{Data: []byte("-- +goose Up\n")}
+		p := NewProvider(Filesystem(fsys), Dialect(DialectSQLite3))
+		status := p.Verify("migrations")
+		if len(status.Findings) != 1 {
+			t.Fatalf("findings, got %v expected 1 parse error", status.Findings)
+		}
+		for _, f := range status.Findings {
+			if f.File != "migrations/"+CallbackAfterMigrate || f.Code != VerifyCodeParse {
+				t.Errorf("finding, got %v expected a parse error for %s", f, CallbackAfterMigrate)
+			}
+		}
+	})
+}
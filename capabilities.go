@@ -0,0 +1,105 @@
+package goose
+
+import (
+	"regexp"
+)
+
+// DialectCapabilities are the features of a database that change how goose runs migrations against it
+type DialectCapabilities struct {
+	// Transactions is true if the database supports transactions, migrations are not run in a
+	// transaction otherwise, even if they are not annotated with NO TRANSACTION.
+	Transactions bool `json:"transactions"`
+	// TransactionalDDL is true if DDL statements can be rolled back. Otherwise the database commits
+	// the transaction implicitly on a DDL statement, and a failed migration is only partially rolled back.
+	TransactionalDDL bool `json:"transactional_ddl"`
+	// AdvisoryLocks is true if the database has session level advisory locks
+	AdvisoryLocks bool `json:"advisory_locks"`
+	// MultiStatement is true if several statements can be executed in a single Exec
+	MultiStatement bool `json:"multi_statement"`
+	// Returning is true if INSERT, UPDATE and DELETE statements support a RETURNING clause
+	Returning bool `json:"returning"`
+}
+
+// CapabilitiesDialect is implemented by the dialects that declare their capabilities, all the
+// dialects of goose do.
+type CapabilitiesDialect interface {
+	Capabilities() DialectCapabilities
+}
+
+// DefaultCapabilities are the capabilities of a dialect that does not implement CapabilitiesDialect,
+// migrations are run in transactions which are assumed to roll back DDL statements.
+var DefaultCapabilities = DialectCapabilities{Transactions: true, TransactionalDDL: true}
+
+// CapabilitiesOf returns the capabilities of the dialect, DefaultCapabilities if it does not declare them
+func CapabilitiesOf(d SQLDialect) DialectCapabilities {
+	if cd, ok := d.(CapabilitiesDialect); ok {
+		return cd.Capabilities()
+	}
+	return DefaultCapabilities
+}
+
+// Capabilities returns the capabilities of the provider's dialect
+func (p *Provider) Capabilities() DialectCapabilities { return CapabilitiesOf(p.dialect) }
+
+func (PostgresDialect) Capabilities() DialectCapabilities {
+	return DialectCapabilities{Transactions: true, TransactionalDDL: true, AdvisoryLocks: true, MultiStatement: true, Returning: true}
+}
+
+func (MySQLDialect) Capabilities() DialectCapabilities {
+	// multiple statements need the multiStatements parameter of the driver, so are not assumed
+	return DialectCapabilities{Transactions: true, AdvisoryLocks: true}
+}
+
+func (TiDBDialect) Capabilities() DialectCapabilities {
+	return DialectCapabilities{Transactions: true}
+}
+
+func (Sqlite3Dialect) Capabilities() DialectCapabilities {
+	return DialectCapabilities{Transactions: true, TransactionalDDL: true, MultiStatement: true, Returning: true}
+}
+
+func (SqlServerDialect) Capabilities() DialectCapabilities {
+	return DialectCapabilities{Transactions: true, TransactionalDDL: true, AdvisoryLocks: true, MultiStatement: true}
+}
+
+func (RedshiftDialect) Capabilities() DialectCapabilities {
+	return DialectCapabilities{Transactions: true, TransactionalDDL: true, MultiStatement: true}
+}
+
+func (ClickHouseDialect) Capabilities() DialectCapabilities {
+	return DialectCapabilities{}
+}
+
+func (d *GenericDialect) Capabilities() DialectCapabilities {
+	if d.config.Capabilities == nil {
+		return DefaultCapabilities
+	}
+	return *d.config.Capabilities
+}
+
+var (
+	_ CapabilitiesDialect = PostgresDialect{}
+	_ CapabilitiesDialect = MySQLDialect{}
+	_ CapabilitiesDialect = TiDBDialect{}
+	_ CapabilitiesDialect = Sqlite3Dialect{}
+	_ CapabilitiesDialect = SqlServerDialect{}
+	_ CapabilitiesDialect = RedshiftDialect{}
+	_ CapabilitiesDialect = ClickHouseDialect{}
+	_ CapabilitiesDialect = (*GenericDialect)(nil)
+)
+
+// matchDDL matches the statements, with comments removed, that change the schema
+var matchDDL = regexp.MustCompile(`(?i)^\s*(CREATE|ALTER|DROP|TRUNCATE|RENAME)\s`)
+
+// isDDL returns if the statement changes the schema
+func isDDL(statement string) bool { return matchDDL.MatchString(clearStatement(statement)) }
+
+// firstDDL returns the index of the first DDL statement, or -1 if none are
+func firstDDL(statements []string) int {
+	for i, s := range statements {
+		if isDDL(s) {
+			return i
+		}
+	}
+	return -1
+}
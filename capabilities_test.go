@@ -0,0 +1,77 @@
+package goose
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	std "log"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// capabilitiesDialect is the sqlite dialect with the capabilities of another database
+type capabilitiesDialect struct {
+	Sqlite3Dialect
+	caps DialectCapabilities
+}
+
+func (d *capabilitiesDialect) Capabilities() DialectCapabilities { return d.caps }
+
+func TestDialectCapabilities(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/00001_users.sql": {Data: []byte("-- +goose Up\nINSERT INTO goose_db_version (version_id, is_applied) VALUES (100, true);\nCREATE TABLE users (id INTEGER);\nSELECT * FROM missing;\n-- +goose Down\nDROP TABLE users;\n")},
+	}
+	run := func(t *testing.T, caps DialectCapabilities) (MigrationError, string, *sql.DB) {
+		t.Helper()
+		db := openSQLite(t)
+		var buff bytes.Buffer
+		p := NewProvider(Filesystem(fsys), DialectObject(&capabilitiesDialect{caps: caps}), Log(std.New(&buff, "", 0)))
+		var merr MigrationError
+		if err := p.Up(db, "migrations", WithNoOutput()); !errors.As(err, &merr) {
+			t.Fatalf("up, got %v expected a MigrationError", err)
+		}
+		return merr, buff.String(), db
+	}
+
+	t.Run("transactional ddl", func(t *testing.T) {
+		t.Parallel()
+		merr, output, _ := run(t, DefaultCapabilities)
+		if !merr.InTransaction || !merr.RolledBack || merr.ImplicitCommit {
+			t.Errorf("error, got %+v expected it to be rolled back", merr)
+		}
+		if strings.Contains(output, "WARNING") {
+			t.Errorf("output, got %q expected no warning", output)
+		}
+	})
+
+	t.Run("implicit commit", func(t *testing.T) {
+		t.Parallel()
+		merr, output, _ := run(t, DialectCapabilities{Transactions: true})
+		if !merr.InTransaction || merr.RolledBack || !merr.ImplicitCommit {
+			t.Errorf("error, got %+v expected it to not be rolled back", merr)
+		}
+		if !strings.Contains(merr.Error(), "DDL was committed implicitly") {
+			t.Errorf("error, got %q expected it to report the implicit commit", merr.Error())
+		}
+		if !strings.Contains(output, "WARNING 00001_users.sql: DDL is committed implicitly") {
+			t.Errorf("output, got %q expected a warning", output)
+		}
+	})
+
+	t.Run("no transactions", func(t *testing.T) {
+		t.Parallel()
+		merr, _, db := run(t, DialectCapabilities{})
+		if merr.InTransaction || merr.RolledBack {
+			t.Errorf("error, got %+v expected it to not be in a transaction", merr)
+		}
+		if _, err := db.Exec("SELECT * FROM users"); err != nil {
+			t.Errorf("table users, expected it to exist: %v", err)
+		}
+	})
+
+	if CapabilitiesOf(&ClickHouseDialect{}).Transactions || !CapabilitiesOf(&PostgresDialect{}).TransactionalDDL || CapabilitiesOf(&MySQLDialect{}).TransactionalDDL {
+		t.Errorf("capabilities, got the wrong capabilities for the built in dialects")
+	}
+}
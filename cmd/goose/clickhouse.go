@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/gdey/goose/v3"
+)
+
+var (
+	clickhouseCluster           = flags.String("clickhouse-cluster", "", "cluster the clickhouse version table is created on, with ON CLUSTER")
+	clickhouseEngine            = flags.String("clickhouse-engine", "", "engine of the clickhouse version table (default MergeTree, or ReplicatedMergeTree with -clickhouse-cluster)")
+	clickhouseSettings          = flags.String("clickhouse-settings", "", "SETTINGS of the clickhouse version table, e.g. index_granularity = 8192")
+	clickhouseLightweightDelete = flags.Bool("clickhouse-lightweight-delete", false, "delete versions with a lightweight DELETE FROM instead of an ALTER TABLE ... DELETE mutation")
+)
+
+// configureClickHouse sets the -clickhouse-* flags on the dialect, if it is the clickhouse dialect
+func configureClickHouse(dialect goose.SQLDialect) {
+	d, ok := dialect.(*goose.ClickHouseDialect)
+	if !ok {
+		return
+	}
+	d.Cluster = *clickhouseCluster
+	d.Engine = *clickhouseEngine
+	d.Settings = *clickhouseSettings
+	d.LightweightDelete = *clickhouseLightweightDelete
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gdey/goose/v3"
+)
+
+var (
+	genericConfig        = flags.String("generic-config", "", "JSON file configuring a generic dialect, for a driver goose has no dialect for, see README")
+	genericPlaceholder   = flags.String("generic-placeholder", "", "placeholder style of the generic dialect: $, ?, @p or :")
+	genericQuote         = flags.String("generic-quote", "", "identifier quote characters of the generic dialect, e.g. \" or []")
+	genericDDL           = flags.String("generic-ddl", "", "text/template of the version table DDL of the generic dialect")
+	genericAutoIncrement = flags.String("generic-autoincrement", "", "id column type of the generic dialect's version table")
+	genericTimestamp     = flags.String("generic-timestamp", "", "timestamp column type of the generic dialect's tables")
+	genericOrderBy       = flags.String("generic-order-by", "", "column the generic dialect orders the version table by")
+	genericAlterDelete   = flags.Bool("generic-alter-delete", false, "delete with ALTER TABLE ... DELETE in the generic dialect")
+)
+
+// genericDialect returns the generic dialect configured by the -generic-config file and the -generic-*
+// flags, the flags overriding the file, or nil if none of them are set.
+func genericDialect() (goose.SQLDialect, error) {
+	set := false
+	flags.Visit(func(f *flag.Flag) { set = set || strings.HasPrefix(f.Name, "generic-") })
+	if !set {
+		return nil, nil
+	}
+	var config goose.GenericConfig
+	if *genericConfig != "" {
+		f, err := os.Open(*genericConfig)
+		if err != nil {
+			return nil, err
+		}
+		config, err = goose.ReadGenericConfig(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("-generic-config=%q: %w", *genericConfig, err)
+		}
+	}
+	for _, override := range []struct {
+		value string
+		field *string
+	}{
+		{*genericQuote, &config.Quote},
+		{*genericDDL, &config.VersionTableDDL},
+		{*genericAutoIncrement, &config.AutoIncrement},
+		{*genericTimestamp, &config.Timestamp},
+		{*genericOrderBy, &config.OrderBy},
+	} {
+		if override.value != "" {
+			*override.field = override.value
+		}
+	}
+	if *genericPlaceholder != "" {
+		config.Placeholder = goose.PlaceholderStyle(*genericPlaceholder)
+	}
+	if *genericAlterDelete {
+		config.AlterTableDelete = true
+	}
+	return goose.NewGenericDialect(config)
+}
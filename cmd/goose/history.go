@@ -0,0 +1,95 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gdey/goose/v3"
+)
+
+var (
+	historyVersion = flags.Int64("history-version", 0, "only list the history of the version, with history")
+	since          = flags.String("since", "", "only list the history at or after the date, as 2006-01-02 or RFC3339, with history")
+	until          = flags.String("until", "", "only list the history before the date, as 2006-01-02 or RFC3339, with history")
+	recordHistory  = flags.Bool("record-history", false, "record the migrations applied and rolled back in the history table")
+	appliedBy      = flags.String("applied-by", "", "who ran the migrations recorded in the history, by default the user running goose")
+)
+
+// parseHistoryDate parses the date of the -since and -until flags
+func parseHistoryDate(name, value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-%s %q: expected a date as 2006-01-02 or RFC3339", name, value)
+	}
+	return t, nil
+}
+
+// historyOptions returns the filters of the history set by the flags
+func historyOptions() ([]goose.HistoryOption, error) {
+	var opts []goose.HistoryOption
+	if *historyVersion != 0 {
+		opts = append(opts, goose.HistoryVersion(*historyVersion))
+	}
+	if *since != "" {
+		t, err := parseHistoryDate("since", *since)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, goose.HistorySince(t))
+	}
+	if *until != "" {
+		t, err := parseHistoryDate("until", *until)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, goose.HistoryUntil(t))
+	}
+	return opts, nil
+}
+
+// history writes the history of the migrations of the database to w, in the -format.
+func history(w io.Writer, db *sql.DB) error {
+	opts, err := historyOptions()
+	if err != nil {
+		return err
+	}
+	entries, err := goose.History(db, opts...)
+	if err != nil {
+		return err
+	}
+	return printHistory(w, *format, entries)
+}
+
+// printHistory writes the entries of the history to w, in the given format.
+func printHistory(w io.Writer, format string, entries []goose.HistoryEntry) error {
+	switch format {
+	case "", "text":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "TIMESTAMP\tVERSION\tDIRECTION\tAPPLIED BY\tDURATION")
+		for _, entry := range entries {
+			duration := ""
+			if entry.Duration != 0 {
+				duration = entry.Duration.String()
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", entry.Timestamp.Format(time.ANSIC), entry.Version,
+				entry.Direction, entry.AppliedBy, duration)
+		}
+		return tw.Flush()
+	case "json":
+		if entries == nil {
+			entries = []goose.HistoryEntry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	default:
+		return fmt.Errorf("%q: unknown format for history, expected text or json", format)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"errors"
 	"flag"
 	"fmt"
@@ -8,24 +9,39 @@ import (
 	"log"
 	"os"
 	"runtime/debug"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/gdey/goose/v3"
 )
 
 var (
-	flags        = flag.NewFlagSet("goose", flag.ExitOnError)
-	dir          = flags.String("dir", defaultMigrationDir, "directory with migration files")
-	table        = flags.String("table", "goose_db_version", "migrations table name")
-	verbose      = flags.Bool("v", false, "enable verbose mode")
-	help         = flags.Bool("h", false, "print help")
-	version      = flags.Bool("version", false, "print version")
-	certfile     = flags.String("certfile", "", "file path to root CA's certificates in pem format (only support on mysql)")
-	sequential   = flags.Bool("s", false, "use sequential numbering for new migrations")
-	allowMissing = flags.Bool("allow-missing", false, "applies missing (out-of-order) migrations")
-	sslcert      = flags.String("ssl-cert", "", "file path to SSL certificates in pem format (only support on mysql)")
-	sslkey       = flags.String("ssl-key", "", "file path to SSL key in pem format (only support on mysql)")
-	noVersioning = flags.Bool("no-versioning", false, "apply migration commands with no versioning, in file order, from directory pointed to")
+	flags         = flag.NewFlagSet("goose", flag.ExitOnError)
+	dir           = flags.String("dir", defaultMigrationDir, "directory with migration files")
+	table         = flags.String("table", "goose_db_version", "migrations table name")
+	tableSchema   = flags.String("table-schema", "", "schema of the migrations table, by default the schema of the connection")
+	createSchema  = flags.Bool("create-schema", false, "create the schema of the migrations table if it does not exist")
+	quoteIdents   = flags.Bool("quote-identifiers", false, "quote the migrations table and schema names for the dialect")
+	verbose       = flags.Bool("v", false, "enable verbose mode")
+	help          = flags.Bool("h", false, "print help")
+	version       = flags.Bool("version", false, "print version")
+	certfile      = flags.String("certfile", "", "file path to root CA's certificates in pem format (only support on mysql)")
+	sequential    = flags.Bool("s", false, "use sequential numbering for new migrations")
+	allowMissing  = flags.Bool("allow-missing", false, "applies missing (out-of-order) migrations")
+	sslcert       = flags.String("ssl-cert", "", "file path to SSL certificates in pem format (only support on mysql)")
+	sslkey        = flags.String("ssl-key", "", "file path to SSL key in pem format (only support on mysql)")
+	noVersioning  = flags.Bool("no-versioning", false, "apply migration commands with no versioning, in file order, from directory pointed to")
+	dryRun        = flags.Bool("dry-run", false, "print the renames fix would make without renaming any files")
+	format        = flags.String("format", "text", "output format for verify and history: text or json, for status: text, table, markdown, json or yaml")
+	eventsJSON    = flags.String("events-json", "", "write the migration events as newline-delimited JSON to the file, - for stdout")
+	tags          = flags.String("tags", "", "comma separated tags of the migrations to apply, tags prefixed with ! are not applied")
+	targetsFile   = flags.String("targets", "", "run the command against each of the targets in the file, see README")
+	concurrency   = flags.Int("concurrency", 1, "number of targets to run the command against at the same time, with -targets")
+	continueOnErr = flags.Bool("continue-on-error", false, "run the command against all the targets, even after it failed for one, with -targets")
+	pendingOnly   = flags.Bool("pending", false, "only list the migrations that have not been applied, with status")
+	appliedOnly   = flags.Bool("applied", false, "only list the migrations that have been applied, with status")
+	readOnly      = flags.Bool("read-only", false, "never change the database: status and version do not create the version table, other commands fail")
 )
 var (
 	gooseVersion = ""
@@ -49,6 +65,8 @@ func main() {
 		goose.SetSequential(true)
 	}
 	goose.SetTableName(*table)
+	goose.SetTableSchema(*tableSchema, *createSchema)
+	goose.SetQuoteIdentifiers(*quoteIdents)
 
 	args := flags.Args()
 	if len(args) == 0 || *help {
@@ -73,20 +91,30 @@ func main() {
 		}
 		return
 	case "fix":
-		if err := goose.Run("fix", nil, *dir); err != nil {
+		var options []goose.OptionsFunc
+		if *dryRun {
+			options = append(options, goose.WithDryRun())
+		}
+		if err := goose.RunWithOptions("fix", nil, *dir, nil, options...); err != nil {
 			log.Fatalf("goose run: %v", err)
 		}
 		return
 	case "verify":
 		status := goose.Verify(*dir)
-		if status.Error != nil {
-			log.Printf("got the following error:\n%v\n", status.Error)
+		if err := printVerifyStatus(os.Stdout, *format, status); err != nil {
+			log.Fatalf("goose run: %v", err)
 		}
 		os.Exit(status.Status)
 		return
 	}
 
 	args = mergeArgs(args)
+	if *targetsFile != "" {
+		if err := runTargets(args); err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		return
+	}
 	if len(args) < 3 {
 		flags.Usage()
 		return
@@ -100,21 +128,77 @@ func main() {
 	if driver == "sqlite3" {
 		driver = "sqlite"
 	}
-	db, err := goose.OpenDBWithDriver(driver, normalizeDBString(driver, dbstring, *certfile, *sslcert, *sslkey))
+	dialect, err := genericDialect()
+	if err != nil {
+		log.Fatalf("goose: %v\n", err)
+	}
+	db, err := openDB(driver, normalizeDBString(driver, dbstring, *certfile, *sslcert, *sslkey), dialect)
 	if err != nil {
 		log.Fatalf("-dbstring=%q: %v\n", dbstring, err)
 	}
-	defer func() {
-		if err := db.Close(); err != nil {
-			log.Fatalf("goose: failed to close DB: %v\n", err)
-		}
-	}()
+	configureClickHouse(goose.GetDialect())
 
 	arguments := []string{}
 	if len(args) > 3 {
 		arguments = append(arguments, args[3:]...)
 	}
 
+	closeEvents := subscribeEventsJSON()
+	err = runDBCommand(db, command, arguments)
+	// log.Fatalf does not run the deferred functions, the events file and the database are closed first
+	closeEvents()
+	closeErr := db.Close()
+	if err != nil {
+		log.Fatalf("goose run: %v", err)
+	}
+	if closeErr != nil {
+		log.Fatalf("goose: failed to close DB: %v\n", closeErr)
+	}
+}
+
+// runDBCommand runs the command against the database, with the arguments after the command
+func runDBCommand(db *sql.DB, command string, arguments []string) error {
+	switch command {
+	case "verify-db":
+		return verifyDB(os.Stdout, db)
+	case "prune-orphans":
+		return pruneOrphans(os.Stdin, os.Stdout, db)
+	case "history":
+		return history(os.Stdout, db)
+	}
+	if command == "status" && statusReportRequested() {
+		rows, err := goose.StatusReport(db, *dir, commandOptions()...)
+		if err != nil && !errors.As(err, &goose.ErrNotInitialized{}) {
+			return err
+		}
+		if err := printStatusReport(os.Stdout, *format, filterStatusRows(rows)); err != nil {
+			return err
+		}
+		if err != nil {
+			log.Printf("goose: not initialized, version table %s does not exist\n", goose.TableName())
+		}
+		return nil
+	}
+
+	return goose.RunWithOptions(
+		command,
+		db,
+		*dir,
+		arguments,
+		commandOptions()...,
+	)
+}
+
+// openDB opens the database, with the generic dialect if it is configured
+func openDB(driver, dbstring string, dialect goose.SQLDialect) (*sql.DB, error) {
+	if dialect != nil {
+		return goose.OpenDBWithDialect(driver, dbstring, dialect)
+	}
+	return goose.OpenDBWithDriver(driver, dbstring)
+}
+
+// commandOptions returns the options set by the flags for the commands run against a database
+func commandOptions() []goose.OptionsFunc {
 	options := []goose.OptionsFunc{}
 	if *allowMissing {
 		options = append(options, goose.WithAllowMissing())
@@ -122,17 +206,117 @@ func main() {
 	if *noVersioning {
 		options = append(options, goose.WithNoVersioning())
 	}
-	if err := goose.RunWithOptions(
-		command,
-		db,
-		*dir,
-		arguments,
-		options...,
-	); err != nil {
-		log.Fatalf("goose run: %v", err)
+	if *readOnly {
+		options = append(options, goose.WithReadOnly())
+	}
+	if *recordHistory {
+		options = append(options, goose.WithHistory())
+	}
+	if *appliedBy != "" {
+		options = append(options, goose.WithAppliedBy(*appliedBy))
+	}
+	return append(options, tagOptions(*tags)...)
+}
+
+// subscribeEventsJSON subscribes the -events-json file to the events, the returned function
+// unsubscribes and closes the file, it must be called before exiting with log.Fatalf.
+func subscribeEventsJSON() func() {
+	if *eventsJSON == "" {
+		return func() {}
+	}
+	events, err := openEventsJSON(*eventsJSON)
+	if err != nil {
+		log.Fatalf("-events-json=%q: %v\n", *eventsJSON, err)
+	}
+	sub := goose.Subscribe(events.Handle)
+	return func() {
+		goose.Unsubscribe(sub)
+		if err := events.Close(); err != nil {
+			log.Fatalf("goose: failed to write events: %v\n", err)
+		}
 	}
 }
 
+// runTargets runs the command against each of the targets in the -targets file, args are
+// [DRIVER DBSTRING] COMMAND [ARGS], the driver and dbstring being needed for schema targets.
+func runTargets(args []string) error {
+	var driver, dbstring string
+	if len(args) >= 3 && isDriver(args[0]) {
+		driver, dbstring, args = args[0], args[1], args[2:]
+	}
+	if len(args) == 0 {
+		flags.Usage()
+		return nil
+	}
+	f, err := os.Open(*targetsFile)
+	if err != nil {
+		return err
+	}
+	targets, err := goose.ReadTargets(f, driver, dbstring)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("-targets=%q: %w", *targetsFile, err)
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("-targets=%q: no targets", *targetsFile)
+	}
+	switch {
+	case args[0] == "status" && statusReportRequested():
+		return fmt.Errorf("-format, -pending and -applied of status are not supported with -targets")
+	case args[0] == "history":
+		return fmt.Errorf("history is not supported with -targets")
+	case args[0] == "prune-orphans" && !*assumeYes:
+		return fmt.Errorf("prune-orphans can not ask for confirmation with -targets, set -yes")
+	}
+	for i := range targets {
+		// treat sqlite3 as sqlite, see main
+		if targets[i].Driver == "sqlite3" {
+			targets[i].Driver = "sqlite"
+		}
+		targets[i].DBString = normalizeDBString(targets[i].Driver, targets[i].DBString, *certfile, *sslcert, *sslkey)
+	}
+	dialect, err := genericDialect()
+	if err != nil {
+		return err
+	}
+	if dialect != nil {
+		err = goose.SetDialectObject(dialect)
+	} else {
+		// the targets of other dialects than the first's are rejected by the FanOut
+		err = goose.SetDialect(targets[0].Driver)
+	}
+	if err != nil {
+		return err
+	}
+	configureClickHouse(goose.GetDialect())
+	defer subscribeEventsJSON()()
+
+	report := goose.NewFanOut(nil, targets,
+		goose.FanOutConcurrency(*concurrency),
+		goose.FanOutContinueOnError(*continueOnErr),
+	).Run(args[0], *dir, args[1:], commandOptions()...)
+	for _, result := range report.Results {
+		switch {
+		case result.Skipped:
+			log.Printf("%-20s skipped\n", result.Target)
+		case result.Err != nil:
+			log.Printf("%-20s FAILED (%v): %v\n", result.Target, result.Duration.Round(time.Millisecond), result.Err)
+		default:
+			log.Printf("%-20s OK (%v)\n", result.Target, result.Duration.Round(time.Millisecond))
+		}
+	}
+	if failed := len(report.Failed()); failed > 0 {
+		return fmt.Errorf("failed for %d of %d targets", failed, len(targets))
+	}
+	return nil
+}
+
+// isDriver returns if name is one of the drivers goose supports
+func isDriver(name string) bool {
+	_, err := goose.SelectDialect("", name)
+	return err == nil
+}
+
 const (
 	envGooseDriver       = "GOOSE_DRIVER"
 	envGooseDBString     = "GOOSE_DBSTRING"
@@ -143,6 +327,38 @@ const (
 	defaultMigrationDir = "."
 )
 
+// openEventsJSON returns the writer for the -events-json file, - is stdout
+func openEventsJSON(name string) (*goose.JSONEventWriter, error) {
+	if name == "-" {
+		return goose.NewJSONEventWriter(os.Stdout), nil
+	}
+	return goose.CreateJSONEventFile(name)
+}
+
+// tagOptions returns the options for the -tags flag, e.g. dev,!perf applies the migrations tagged dev
+// but not those tagged perf
+func tagOptions(list string) []goose.OptionsFunc {
+	var with, without []string
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		switch {
+		case tag == "" || tag == "!":
+		case strings.HasPrefix(tag, "!"):
+			without = append(without, tag[1:])
+		default:
+			with = append(with, tag)
+		}
+	}
+	var options []goose.OptionsFunc
+	if len(with) > 0 {
+		options = append(options, goose.WithTags(with...))
+	}
+	if len(without) > 0 {
+		options = append(options, goose.WithoutTags(without...))
+	}
+	return options
+}
+
 func mergeArgs(args []string) []string {
 	if len(args) < 1 {
 		return args
@@ -157,7 +373,14 @@ func mergeArgs(args []string) []string {
 }
 
 func usage() {
-	fmt.Println(usagePrefix)
+	fmt.Print(usagePrefix)
+	// the dialects registered by a custom binary, see goose.RegisterDialect
+	for _, name := range goose.Dialects() {
+		if !usageDrivers[name] {
+			fmt.Printf("    %s\n", name)
+		}
+	}
+	fmt.Println(usageExamples)
 	flags.PrintDefaults()
 	fmt.Println(usageCommands)
 }
@@ -173,6 +396,10 @@ GOOSE_DBSTRING=DBSTRING
 
 Usage: goose [OPTIONS] COMMAND
 
+or, to run the command against each of the targets in FILE
+
+Usage: goose -targets FILE [OPTIONS] [DRIVER DBSTRING] COMMAND
+
 Drivers:
     postgres
     mysql
@@ -181,7 +408,14 @@ Drivers:
     redshift
     tidb
     clickhouse
+`
+	// usageDrivers are the drivers listed in usagePrefix, and their aliases
+	usageDrivers = map[string]bool{
+		"postgres": true, "pgx": true, "mysql": true, "sqlite3": true, "sqlite": true,
+		"mssql": true, "redshift": true, "tidb": true, "clickhouse": true,
+	}
 
+	usageExamples = `
 Examples:
     goose sqlite3 ./foo.db status
     goose sqlite3 ./foo.db create init sql
@@ -215,11 +449,16 @@ Commands:
     down-to VERSION      Roll back to a specific VERSION
     redo                 Re-run the latest migration
     reset                Roll back all migrations
-    status               Dump the migration status for the current DB
+    status               Dump the migration status for the current DB, use -format to choose the output
     version              Print the current version of the database
+    seed [SET]           Apply the seeds of the seed set, the default set if SET is not given
+    seed-reset [SET]     Roll back all the seeds of the seed set
     create NAME [tpl|sql|go] Creates new migration file with the current timestamp
-    fix                  Apply sequential ordering to migrations
-    verify               Check to see if there are any timestamp-based sql files, or if template sqls don't parse 
+    fix                  Apply sequential ordering to migrations, use -dry-run to only print the renames
+    verify               Check the migrations for problems, use -format=json for machine readable output
+    verify-db            Check the DB for orphaned versions, applied versions that have no migration
+    prune-orphans        Remove the version rows of the orphaned versions, use -yes to not ask for confirmation
+    history              List the migrations applied and rolled back, use -history-version, -since and -until to filter
 `
 )
 
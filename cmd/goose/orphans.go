@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gdey/goose/v3"
+)
+
+var assumeYes = flags.Bool("yes", false, "remove the orphaned versions without asking for confirmation, with prune-orphans")
+
+// formatVersions returns the versions as a comma separated list
+func formatVersions(versions []int64) string {
+	s := make([]string, len(versions))
+	for i, v := range versions {
+		s[i] = fmt.Sprint(v)
+	}
+	return strings.Join(s, ", ")
+}
+
+// verifyDB reports the orphaned versions of the database, the returned error is an
+// ErrOrphanedVersions if there are any.
+func verifyDB(w io.Writer, db *sql.DB) error {
+	if err := goose.VerifyDB(db, *dir); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "goose: no orphaned versions")
+	return err
+}
+
+// pruneOrphans removes the orphaned versions of the database, after asking for confirmation on
+// in unless -yes is set.
+func pruneOrphans(in io.Reader, w io.Writer, db *sql.DB) error {
+	options := append(commandOptions(), goose.WithDryRun())
+	orphans, err := goose.PruneOrphans(db, *dir, options...)
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		_, err := fmt.Fprintln(w, "goose: no orphaned versions")
+		return err
+	}
+	if !*assumeYes {
+		fmt.Fprintf(w, "goose: remove the version rows of the orphaned versions %s? [y/N] ", formatVersions(orphans))
+		answer, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			_, err := fmt.Fprintln(w, "goose: nothing removed")
+			return err
+		}
+	}
+	removed, err := goose.PruneOrphans(db, *dir, commandOptions()...)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "goose: removed the orphaned versions %s\n", formatVersions(removed))
+	return err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gdey/goose/v3"
+	"gopkg.in/yaml.v2"
+)
+
+// statusReportRequested returns if the status command has to be run as a StatusReport, because of
+// its -format or filter flags
+func statusReportRequested() bool {
+	return (*format != "" && *format != "text") || *pendingOnly || *appliedOnly
+}
+
+// filterStatusRows returns the rows left by the -pending and -applied flags
+func filterStatusRows(rows []goose.StatusRow) []goose.StatusRow {
+	if *pendingOnly == *appliedOnly {
+		return rows
+	}
+	var filtered []goose.StatusRow
+	for _, row := range rows {
+		if row.Applied() == *appliedOnly {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
+// printStatusReport writes the rows of the status report to w, in the given format.
+func printStatusReport(w io.Writer, format string, rows []goose.StatusRow) error {
+	appliedAt := func(row goose.StatusRow) string {
+		if row.AppliedAt == nil {
+			return ""
+		}
+		return row.AppliedAt.Format(time.ANSIC)
+	}
+	script := func(row goose.StatusRow) string {
+		if row.Source == "" {
+			return "(no migration)"
+		}
+		return filepath.Base(row.Source)
+	}
+	version := func(row goose.StatusRow) string {
+		if row.Repeatable {
+			return "R"
+		}
+		return fmt.Sprint(row.Version)
+	}
+	switch format {
+	case "", "text":
+		fmt.Fprintln(w, "    Applied At                  Migration")
+		fmt.Fprintln(w, "    =======================================")
+		for _, row := range rows {
+			at := appliedAt(row)
+			if row.State != goose.StateApplied {
+				at = string(row.State)
+			}
+			if _, err := fmt.Fprintf(w, "    %-24s -- %v\n", at, script(row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tTYPE\tSTATE\tAPPLIED AT\tTAGS\tMIGRATION")
+		for _, row := range rows {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", version(row), row.Type, row.State, appliedAt(row),
+				strings.Join(row.Tags, ","), script(row))
+		}
+		return tw.Flush()
+	case "markdown":
+		fmt.Fprintln(w, "| Version | Type | State | Applied At | Tags | Migration |")
+		fmt.Fprintln(w, "|---------|------|-------|------------|------|-----------|")
+		for _, row := range rows {
+			if _, err := fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s |\n", version(row), row.Type, row.State,
+				appliedAt(row), strings.Join(row.Tags, ", "), strings.ReplaceAll(script(row), "|", `\|`)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		if rows == nil {
+			rows = []goose.StatusRow{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "yaml":
+		if rows == nil {
+			rows = []goose.StatusRow{}
+		}
+		out, err := yaml.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	default:
+		return fmt.Errorf("%q: unknown format for status, expected text, table, markdown, json or yaml", format)
+	}
+}
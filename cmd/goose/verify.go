@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gdey/goose/v3"
+)
+
+// printVerifyStatus writes the findings of the verify status to w, in the given format.
+func printVerifyStatus(w io.Writer, format string, status goose.VerifyStatus) error {
+	switch format {
+	case "", "text":
+		if len(status.Findings) == 0 && status.Error != nil {
+			_, err := fmt.Fprintf(w, "got the following error:\n%v\n", status.Error)
+			return err
+		}
+		for _, f := range status.Findings {
+			if _, err := fmt.Fprintln(w, f.Error()); err != nil {
+				return err
+			}
+		}
+		if len(status.Findings) == 0 {
+			_, err := fmt.Fprintln(w, "no problems found")
+			return err
+		}
+		return nil
+	case "json":
+		report := struct {
+			Status   int                   `json:"status"`
+			Error    string                `json:"error,omitempty"`
+			Findings []goose.VerifyFinding `json:"findings"`
+		}{
+			Status:   status.Status,
+			Findings: status.Findings,
+		}
+		if report.Findings == nil {
+			report.Findings = []goose.VerifyFinding{}
+		}
+		if len(status.Findings) == 0 && status.Error != nil {
+			report.Error = status.Error.Error()
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	default:
+		return fmt.Errorf("%q: unknown format for verify, expected text or json", format)
+	}
+}
@@ -17,7 +17,11 @@ func (p *Provider) OpenDBWithDriver(driver string, dbstring string) (*sql.DB, er
 	if err := p.SetDialect(driver); err != nil {
 		return nil, err
 	}
+	return openDB(driver, dbstring)
+}
 
+// openDB opens the database with the sql driver for the goose driver name
+func openDB(driver string, dbstring string) (*sql.DB, error) {
 	switch driver {
 	case "mssql":
 		driver = "sqlserver"
@@ -31,6 +35,10 @@ func (p *Provider) OpenDBWithDriver(driver string, dbstring string) (*sql.DB, er
 	case "postgres", "pgx", "sqlite3", "sqlite", "mysql", "sqlserver", "clickhouse":
 		return sql.Open(driver, dbstring)
 	default:
+		// a registered dialect, or a GenericDialect, is opened with the database/sql driver
+		if isSQLDriver(driver) {
+			return sql.Open(driver, dbstring)
+		}
 		return nil, fmt.Errorf("unsupported driver %s", driver)
 	}
 }
@@ -2,7 +2,11 @@ package goose
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 )
 
 const (
@@ -16,14 +20,34 @@ const (
 )
 
 // SQLDialect abstracts the details of specific SQL dialects
-// for goose's few SQL specific statements
+// for goose's few SQL specific statements. A dialect for another database is added with
+// RegisterDialect, it usually embeds BaseDialect for the table name and schema, and can be
+// checked with the dialecttest package.
+//
+// The statements use the placeholders of the database's driver. DBVersionQuery returns the
+// version_id and is_applied of each row of the version table, the most recent first; MigrationSQL
+// the tstamp and is_applied of the most recent row for a version_id; RepeatableQuerySQL the name,
+// checksum and tstamp of each repeatable migration; and TagsQuerySQL the version_id and tags of
+// each row of the version table the tags active when it was applied are recorded in, the nullable
+// tags column of the version table.
 type SQLDialect interface {
-	SetTableName(name string)      // set table name to use for SQL generation
-	createVersionTableSQL() string // sql string to create the db version table
-	insertVersionSQL() string      // sql string to insert the initial version table row
-	deleteVersionSQL() string      // sql string to delete version
-	migrationSQL() string          // sql string to retrieve migrations
-	dbVersionQuery(db *sql.DB) (*sql.Rows, error)
+	SetTableName(name string)           // set table name to use for SQL generation
+	SetSchema(name string, create bool) // set the schema of the table, and if it is to be created
+	CreateSchemaSQL() string            // sql string to create the schema of the table, empty if there is nothing to create
+	CreateVersionTableSQL() string      // sql string to create the db version table
+	InsertVersionSQL() string           // sql string to insert the initial version table row
+	DeleteVersionSQL() string           // sql string to delete version
+	MigrationSQL() string               // sql string to retrieve migrations
+	DBVersionQuery(db *sql.DB) (*sql.Rows, error)
+
+	CreateRepeatableTableSQL() string // sql string to create the repeatable migrations table
+	InsertRepeatableSQL() string      // sql string to insert the checksum of a repeatable migration
+	DeleteRepeatableSQL() string      // sql string to delete the checksum of a repeatable migration
+	RepeatableQuerySQL() string       // sql string to retrieve the repeatable migrations applied
+
+	AddTagsColumnSQL() string     // sql string to add the tags column to a version table created without it
+	InsertVersionTagsSQL() string // sql string to insert a version row with the tags active when it was applied
+	TagsQuerySQL() string         // sql string to retrieve the recorded tags
 }
 
 // GetDialect gets the SQLDialect
@@ -31,28 +55,69 @@ func GetDialect() SQLDialect {
 	return defaultProvider.dialect
 }
 
-func SelectDialect(tableName, d string) (SQLDialect, error) {
+// DialectFactory returns a new SQLDialect using tableName as the version table
+type DialectFactory func(tableName string) SQLDialect
+
+var dialects = struct {
+	sync.RWMutex
+	factories map[string]DialectFactory
+}{factories: map[string]DialectFactory{
+	DialectPostgres:   func(t string) SQLDialect { return &PostgresDialect{BaseDialect{TableName: t}} },
+	"pgx":             func(t string) SQLDialect { return &PostgresDialect{BaseDialect{TableName: t}} },
+	DialectMySQL:      func(t string) SQLDialect { return &MySQLDialect{BaseDialect{TableName: t}} },
+	DialectSQLite3:    func(t string) SQLDialect { return &Sqlite3Dialect{BaseDialect{TableName: t}} },
+	"sqlite":          func(t string) SQLDialect { return &Sqlite3Dialect{BaseDialect{TableName: t}} },
+	DialectMSSQL:      func(t string) SQLDialect { return &SqlServerDialect{BaseDialect{TableName: t}} },
+	DialectRedShit:    func(t string) SQLDialect { return &RedshiftDialect{BaseDialect{TableName: t}} },
+	DialectTiDB:       func(t string) SQLDialect { return &TiDBDialect{BaseDialect{TableName: t}} },
+	DialectClickHouse: func(t string) SQLDialect { return &ClickHouseDialect{BaseDialect: BaseDialect{TableName: t}} },
+}}
+
+// RegisterDialect makes the dialect returned by factory available under name, to SelectDialect, the
+// Dialect option and the goose command. The goose command opens the database with the database/sql
+// driver registered under the same name. It panics if name is empty, already registered, or if factory
+// is nil, as database/sql's Register does.
+func RegisterDialect(name string, factory DialectFactory) {
+	dialects.Lock()
+	defer dialects.Unlock()
+	if name == "" {
+		panic("goose: RegisterDialect name is empty")
+	}
+	if factory == nil {
+		panic("goose: RegisterDialect factory is nil for " + name)
+	}
+	if _, dup := dialects.factories[name]; dup {
+		panic("goose: RegisterDialect called twice for " + name)
+	}
+	dialects.factories[name] = factory
+}
 
-	base := BaseDialect{TableName: tableName}
-
-	switch d {
-	case DialectPostgres, "pgx":
-		return &PostgresDialect{base}, nil
-	case DialectMySQL:
-		return &MySQLDialect{base}, nil
-	case DialectSQLite3, "sqlite":
-		return &Sqlite3Dialect{base}, nil
-	case DialectMSSQL:
-		return &SqlServerDialect{base}, nil
-	case DialectRedShit:
-		return &RedshiftDialect{base}, nil
-	case DialectTiDB:
-		return &TiDBDialect{base}, nil
-	case DialectClickHouse:
-		return &ClickHouseDialect{base}, nil
-	default:
-		return nil, fmt.Errorf("%q: unknown dialect", d)
+// Dialects returns the names of the dialects, built in and registered, sorted.
+func Dialects() []string {
+	dialects.RLock()
+	defer dialects.RUnlock()
+	names := make([]string, 0, len(dialects.factories))
+	for name := range dialects.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectDialect returns a new dialect, using tableName as the version table, for the name of a
+// built in or registered dialect.
+func SelectDialect(tableName, d string) (SQLDialect, error) {
+	dialects.RLock()
+	factory, ok := dialects.factories[d]
+	dialects.RUnlock()
+	if !ok {
+		return nil, ErrUnknownDialect{Dialect: d}
+	}
+	dialect := factory(tableName)
+	if dialect == nil {
+		return nil, ErrUnknownDialect{Dialect: d}
 	}
+	return dialect, nil
 }
 
 // Dialect returns the SQLDialect of the provider
@@ -69,19 +134,153 @@ func (p *Provider) SetDialect(d string) error {
 	if err != nil {
 		return err
 	}
+	dialect.SetSchema(p.tableSchema, p.createSchema)
+	setQuoteIdentifiers(dialect, p.quoteIdentifiers)
 	p.dialect = dialect
 	return nil
 }
 
+const (
+	// RepeatableTableSuffix is added to the version table name for the table the checksums of the
+	// repeatable migrations are kept in
+	RepeatableTableSuffix = "_repeatable"
+	// HistoryTableSuffix is added to the version table name for the table the migrations applied and
+	// rolled back are recorded in, see HistoryDialect
+	HistoryTableSuffix = "_history"
+)
+
+// SetDialectObject sets the SQLDialect, see DialectObject
+func SetDialectObject(d SQLDialect) error {
+	return defaultProvider.SetDialectObject(d)
+}
+
+// SetDialectObject sets the SQLDialect, see DialectObject
+func (p *Provider) SetDialectObject(d SQLDialect) error {
+	if d == nil {
+		return errors.New("dialect object must not be nil")
+	}
+	d.SetTableName(p.tableName)
+	d.SetSchema(p.tableSchema, p.createSchema)
+	setQuoteIdentifiers(d, p.quoteIdentifiers)
+	p.dialect = d
+	return nil
+}
+
 // BaseDialect struct.
 type BaseDialect struct {
+	// TableName is the name of the version table. If Schema is not set, a name with a dot, e.g.
+	// app.goose_db_version, is the schema and the name of the table.
 	TableName string
+	// Schema is the schema the version table is in, the default schema of the connection if empty
+	Schema string
+	// CreateSchema creates the schema, if it does not exist, before creating the version table
+	CreateSchema bool
+	// QuoteIdentifiers quotes the names of the schema and the version table, so they are used as they
+	// are written instead of as the database folds them, e.g. Postgres folds unquoted names to lower
+	// case. The names already written quoted, e.g. "MyTable", are used as they are either way.
+	QuoteIdentifiers bool
+}
+
+// QuotingDialect is implemented by the dialects that can quote the names of the schema and the version
+// table, the dialects embedding BaseDialect do. See QuoteIdentifiers.
+type QuotingDialect interface {
+	SetQuoteIdentifiers(quote bool)
 }
 
 func (bd *BaseDialect) SetTableName(name string) {
 	bd.TableName = name
 }
 
+func (bd *BaseDialect) SetSchema(name string, create bool) {
+	bd.Schema = name
+	bd.CreateSchema = create
+}
+
+func (bd *BaseDialect) SetQuoteIdentifiers(quote bool) {
+	bd.QuoteIdentifiers = quote
+}
+
+// setQuoteIdentifiers sets if the dialect quotes the names of the schema and the version table, if it can
+func setQuoteIdentifiers(d SQLDialect, quote bool) {
+	if qd, ok := d.(QuotingDialect); ok {
+		qd.SetQuoteIdentifiers(quote)
+	}
+}
+
+// SchemaAndTable returns the schema and the name of the version table, as they are written
+func (bd BaseDialect) SchemaAndTable() (string, string) {
+	if bd.Schema != "" {
+		return bd.Schema, bd.TableName
+	}
+	// the last dot that is not in a quoted name
+	dot, quote := -1, rune(0)
+	for i, r := range bd.TableName {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '`':
+			quote = r
+		case r == '[':
+			quote = ']'
+		case r == '.':
+			dot = i
+		}
+	}
+	if dot >= 0 {
+		return bd.TableName[:dot], bd.TableName[dot+1:]
+	}
+	return "", bd.TableName
+}
+
+// QuotedTable returns the name of the version table, with the suffix, in its schema. The names are
+// quoted with open and close, the quote characters of the dialect, if QuoteIdentifiers is set or
+// they are written quoted.
+func (bd BaseDialect) QuotedTable(open, close, suffix string) string {
+	schema, table := bd.SchemaAndTable()
+	name := bd.ident(open, close, table, suffix)
+	if schema == "" {
+		return name
+	}
+	return bd.ident(open, close, schema, "") + "." + name
+}
+
+// QuotedSchema returns the schema of the version table, quoted as QuotedTable does, or an empty string
+// if the schema is not to be created.
+func (bd BaseDialect) QuotedSchema(open, close string) string {
+	schema, _ := bd.SchemaAndTable()
+	if !bd.CreateSchema || schema == "" {
+		return ""
+	}
+	return bd.ident(open, close, schema, "")
+}
+
+// ident returns the name with the suffix, quoted with open and close if QuoteIdentifiers is set. A name
+// written quoted stays quoted, with the suffix inside the quotes.
+func (bd BaseDialect) ident(open, close, name, suffix string) string {
+	if unquoted, ok := UnquoteIdent(open, close, name); ok {
+		return QuoteIdent(open, close, unquoted+suffix)
+	}
+	if bd.QuoteIdentifiers {
+		return QuoteIdent(open, close, name+suffix)
+	}
+	return name + suffix
+}
+
+// QuoteIdent quotes the identifier with open and close, close is escaped by doubling it
+func QuoteIdent(open, close, name string) string {
+	return open + strings.ReplaceAll(name, close, close+close) + close
+}
+
+// UnquoteIdent returns the identifier quoted with open and close without its quotes, and if it was quoted
+func UnquoteIdent(open, close, name string) (string, bool) {
+	if open == "" || len(name) < len(open)+len(close) || !strings.HasPrefix(name, open) || !strings.HasSuffix(name, close) {
+		return name, false
+	}
+	return strings.ReplaceAll(name[len(open):len(name)-len(close)], close+close, close), true
+}
+
 ////////////////////////////
 // Postgres
 ////////////////////////////
@@ -89,22 +288,34 @@ func (bd *BaseDialect) SetTableName(name string) {
 // PostgresDialect struct.
 type PostgresDialect struct{ BaseDialect }
 
-func (d PostgresDialect) createVersionTableSQL() string {
+// table returns the quoted name of the version table, with the suffix, in its schema
+func (d PostgresDialect) table(suffix string) string { return d.QuotedTable(`"`, `"`, suffix) }
+
+func (d PostgresDialect) CreateSchemaSQL() string {
+	schema := d.QuotedSchema(`"`, `"`)
+	if schema == "" {
+		return ""
+	}
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", schema)
+}
+
+func (d PostgresDialect) CreateVersionTableSQL() string {
 	return fmt.Sprintf(`CREATE TABLE %s (
             	id serial NOT NULL,
                 version_id bigint NOT NULL,
                 is_applied boolean NOT NULL,
                 tstamp timestamp NULL default now(),
+                tags varchar(255) NULL,
                 PRIMARY KEY(id)
-            );`, d.TableName)
+            );`, d.table(""))
 }
 
-func (d PostgresDialect) insertVersionSQL() string {
-	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, $2);", d.TableName)
+func (d PostgresDialect) InsertVersionSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, $2);", d.table(""))
 }
 
-func (d PostgresDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.TableName))
+func (d PostgresDialect) DBVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.table("")))
 	if err != nil {
 		return nil, err
 	}
@@ -112,12 +323,45 @@ func (d PostgresDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
 	return rows, err
 }
 
-func (d PostgresDialect) migrationSQL() string {
-	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=$1 ORDER BY tstamp DESC LIMIT 1", d.TableName)
+func (d PostgresDialect) MigrationSQL() string {
+	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=$1 ORDER BY tstamp DESC LIMIT 1", d.table(""))
 }
 
-func (d PostgresDialect) deleteVersionSQL() string {
-	return fmt.Sprintf("DELETE FROM %s WHERE version_id=$1;", d.TableName)
+func (d PostgresDialect) DeleteVersionSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE version_id=$1;", d.table(""))
+}
+
+func (d PostgresDialect) CreateRepeatableTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+            	name varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(name)
+            );`, d.table(RepeatableTableSuffix))
+}
+
+func (d PostgresDialect) InsertRepeatableSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (name, checksum) VALUES ($1, $2);", d.table(RepeatableTableSuffix))
+}
+
+func (d PostgresDialect) DeleteRepeatableSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE name=$1;", d.table(RepeatableTableSuffix))
+}
+
+func (d PostgresDialect) RepeatableQuerySQL() string {
+	return fmt.Sprintf("SELECT name, checksum, tstamp FROM %s", d.table(RepeatableTableSuffix))
+}
+
+func (d PostgresDialect) AddTagsColumnSQL() string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN tags varchar(255) NULL;", d.table(""))
+}
+
+func (d PostgresDialect) InsertVersionTagsSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tags) VALUES ($1, $2, $3);", d.table(""))
+}
+
+func (d PostgresDialect) TagsQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, tags FROM %s WHERE tags IS NOT NULL ORDER BY id", d.table(""))
 }
 
 ////////////////////////////
@@ -127,22 +371,34 @@ func (d PostgresDialect) deleteVersionSQL() string {
 // MySQLDialect struct.
 type MySQLDialect struct{ BaseDialect }
 
-func (d MySQLDialect) createVersionTableSQL() string {
+// table returns the quoted name of the version table, with the suffix, in its schema
+func (d MySQLDialect) table(suffix string) string { return d.QuotedTable("`", "`", suffix) }
+
+func (d MySQLDialect) CreateSchemaSQL() string {
+	schema := d.QuotedSchema("`", "`")
+	if schema == "" {
+		return ""
+	}
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", schema)
+}
+
+func (d MySQLDialect) CreateVersionTableSQL() string {
 	return fmt.Sprintf(`CREATE TABLE %s (
                 id serial NOT NULL,
                 version_id bigint NOT NULL,
                 is_applied boolean NOT NULL,
                 tstamp timestamp NULL default now(),
+                tags varchar(255) NULL,
                 PRIMARY KEY(id)
-            );`, d.TableName)
+            );`, d.table(""))
 }
 
-func (d MySQLDialect) insertVersionSQL() string {
-	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (?, ?);", d.TableName)
+func (d MySQLDialect) InsertVersionSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (?, ?);", d.table(""))
 }
 
-func (d MySQLDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.TableName))
+func (d MySQLDialect) DBVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.table("")))
 	if err != nil {
 		return nil, err
 	}
@@ -150,12 +406,45 @@ func (d MySQLDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
 	return rows, err
 }
 
-func (d MySQLDialect) migrationSQL() string {
-	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1", d.TableName)
+func (d MySQLDialect) MigrationSQL() string {
+	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1", d.table(""))
+}
+
+func (d MySQLDialect) DeleteVersionSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE version_id=?;", d.table(""))
+}
+
+func (d MySQLDialect) CreateRepeatableTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                name varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(name)
+            );`, d.table(RepeatableTableSuffix))
+}
+
+func (d MySQLDialect) InsertRepeatableSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (name, checksum) VALUES (?, ?);", d.table(RepeatableTableSuffix))
+}
+
+func (d MySQLDialect) DeleteRepeatableSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE name=?;", d.table(RepeatableTableSuffix))
+}
+
+func (d MySQLDialect) RepeatableQuerySQL() string {
+	return fmt.Sprintf("SELECT name, checksum, tstamp FROM %s", d.table(RepeatableTableSuffix))
+}
+
+func (d MySQLDialect) AddTagsColumnSQL() string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN tags varchar(255) NULL;", d.table(""))
 }
 
-func (d MySQLDialect) deleteVersionSQL() string {
-	return fmt.Sprintf("DELETE FROM %s WHERE version_id=?;", d.TableName)
+func (d MySQLDialect) InsertVersionTagsSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tags) VALUES (?, ?, ?);", d.table(""))
+}
+
+func (d MySQLDialect) TagsQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, tags FROM %s WHERE tags IS NOT NULL ORDER BY id", d.table(""))
 }
 
 ////////////////////////////
@@ -165,21 +454,36 @@ func (d MySQLDialect) deleteVersionSQL() string {
 // SqlServerDialect struct.
 type SqlServerDialect struct{ BaseDialect }
 
-func (d SqlServerDialect) createVersionTableSQL() string {
+// table returns the quoted name of the version table, with the suffix, in its schema
+func (d SqlServerDialect) table(suffix string) string { return d.QuotedTable("[", "]", suffix) }
+
+func (d SqlServerDialect) CreateSchemaSQL() string {
+	schema := d.QuotedSchema("[", "]")
+	if schema == "" {
+		return ""
+	}
+	name, _ := d.SchemaAndTable()
+	name, _ = UnquoteIdent("[", "]", name)
+	return fmt.Sprintf("IF SCHEMA_ID(N'%s') IS NULL EXEC('CREATE SCHEMA %s');",
+		strings.ReplaceAll(name, "'", "''"), strings.ReplaceAll(schema, "'", "''"))
+}
+
+func (d SqlServerDialect) CreateVersionTableSQL() string {
 	return fmt.Sprintf(`CREATE TABLE %s (
                 id INT NOT NULL IDENTITY(1,1) PRIMARY KEY,
                 version_id BIGINT NOT NULL,
                 is_applied BIT NOT NULL,
-                tstamp DATETIME NULL DEFAULT CURRENT_TIMESTAMP
-            );`, d.TableName)
+                tstamp DATETIME NULL DEFAULT CURRENT_TIMESTAMP,
+                tags NVARCHAR(255) NULL
+            );`, d.table(""))
 }
 
-func (d SqlServerDialect) insertVersionSQL() string {
-	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (@p1, @p2);", d.TableName)
+func (d SqlServerDialect) InsertVersionSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (@p1, @p2);", d.table(""))
 }
 
-func (d SqlServerDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY id DESC", d.TableName))
+func (d SqlServerDialect) DBVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY id DESC", d.table("")))
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +491,7 @@ func (d SqlServerDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
 	return rows, err
 }
 
-func (d SqlServerDialect) migrationSQL() string {
+func (d SqlServerDialect) MigrationSQL() string {
 	const tpl = `
 WITH Migrations AS
 (
@@ -201,11 +505,43 @@ FROM Migrations
 WHERE RowNumber BETWEEN 1 AND 2
 ORDER BY tstamp DESC
 `
-	return fmt.Sprintf(tpl, d.TableName)
+	return fmt.Sprintf(tpl, d.table(""))
+}
+
+func (d SqlServerDialect) DeleteVersionSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE version_id=@p1;", d.table(""))
+}
+
+func (d SqlServerDialect) CreateRepeatableTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                name NVARCHAR(255) NOT NULL PRIMARY KEY,
+                checksum VARCHAR(64) NOT NULL,
+                tstamp DATETIME NULL DEFAULT CURRENT_TIMESTAMP
+            );`, d.table(RepeatableTableSuffix))
+}
+
+func (d SqlServerDialect) InsertRepeatableSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (name, checksum) VALUES (@p1, @p2);", d.table(RepeatableTableSuffix))
+}
+
+func (d SqlServerDialect) DeleteRepeatableSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE name=@p1;", d.table(RepeatableTableSuffix))
+}
+
+func (d SqlServerDialect) RepeatableQuerySQL() string {
+	return fmt.Sprintf("SELECT name, checksum, tstamp FROM %s", d.table(RepeatableTableSuffix))
 }
 
-func (d SqlServerDialect) deleteVersionSQL() string {
-	return fmt.Sprintf("DELETE FROM %s WHERE version_id=@p1;", d.TableName)
+func (d SqlServerDialect) AddTagsColumnSQL() string {
+	return fmt.Sprintf("ALTER TABLE %s ADD tags NVARCHAR(255) NULL;", d.table(""))
+}
+
+func (d SqlServerDialect) InsertVersionTagsSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tags) VALUES (@p1, @p2, @p3);", d.table(""))
+}
+
+func (d SqlServerDialect) TagsQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, tags FROM %s WHERE tags IS NOT NULL ORDER BY id", d.table(""))
 }
 
 ////////////////////////////
@@ -215,21 +551,28 @@ func (d SqlServerDialect) deleteVersionSQL() string {
 // Sqlite3Dialect struct.
 type Sqlite3Dialect struct{ BaseDialect }
 
-func (d Sqlite3Dialect) createVersionTableSQL() string {
+// table returns the quoted name of the version table, with the suffix, in its schema
+func (d Sqlite3Dialect) table(suffix string) string { return d.QuotedTable(`"`, `"`, suffix) }
+
+// CreateSchemaSQL returns an empty string, a sqlite schema is an attached database which can not be created
+func (d Sqlite3Dialect) CreateSchemaSQL() string { return "" }
+
+func (d Sqlite3Dialect) CreateVersionTableSQL() string {
 	return fmt.Sprintf(`CREATE TABLE %s (
                 id INTEGER PRIMARY KEY AUTOINCREMENT,
                 version_id INTEGER NOT NULL,
                 is_applied INTEGER NOT NULL,
-                tstamp TIMESTAMP DEFAULT (datetime('now'))
-            );`, d.TableName)
+                tstamp TIMESTAMP DEFAULT (datetime('now')),
+                tags TEXT
+            );`, d.table(""))
 }
 
-func (d Sqlite3Dialect) insertVersionSQL() string {
-	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (?, ?);", d.TableName)
+func (d Sqlite3Dialect) InsertVersionSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (?, ?);", d.table(""))
 }
 
-func (d Sqlite3Dialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.TableName))
+func (d Sqlite3Dialect) DBVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.table("")))
 	if err != nil {
 		return nil, err
 	}
@@ -237,12 +580,44 @@ func (d Sqlite3Dialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
 	return rows, err
 }
 
-func (d Sqlite3Dialect) migrationSQL() string {
-	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1", d.TableName)
+func (d Sqlite3Dialect) MigrationSQL() string {
+	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1", d.table(""))
+}
+
+func (d Sqlite3Dialect) DeleteVersionSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE version_id=?;", d.table(""))
+}
+
+func (d Sqlite3Dialect) CreateRepeatableTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                name TEXT NOT NULL PRIMARY KEY,
+                checksum TEXT NOT NULL,
+                tstamp TIMESTAMP DEFAULT (datetime('now'))
+            );`, d.table(RepeatableTableSuffix))
+}
+
+func (d Sqlite3Dialect) InsertRepeatableSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (name, checksum) VALUES (?, ?);", d.table(RepeatableTableSuffix))
+}
+
+func (d Sqlite3Dialect) DeleteRepeatableSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE name=?;", d.table(RepeatableTableSuffix))
+}
+
+func (d Sqlite3Dialect) RepeatableQuerySQL() string {
+	return fmt.Sprintf("SELECT name, checksum, tstamp FROM %s", d.table(RepeatableTableSuffix))
 }
 
-func (d Sqlite3Dialect) deleteVersionSQL() string {
-	return fmt.Sprintf("DELETE FROM %s WHERE version_id=?;", d.TableName)
+func (d Sqlite3Dialect) AddTagsColumnSQL() string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN tags TEXT;", d.table(""))
+}
+
+func (d Sqlite3Dialect) InsertVersionTagsSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tags) VALUES (?, ?, ?);", d.table(""))
+}
+
+func (d Sqlite3Dialect) TagsQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, tags FROM %s WHERE tags IS NOT NULL ORDER BY id", d.table(""))
 }
 
 ////////////////////////////
@@ -252,22 +627,34 @@ func (d Sqlite3Dialect) deleteVersionSQL() string {
 // RedshiftDialect struct.
 type RedshiftDialect struct{ BaseDialect }
 
-func (d RedshiftDialect) createVersionTableSQL() string {
+// table returns the quoted name of the version table, with the suffix, in its schema
+func (d RedshiftDialect) table(suffix string) string { return d.QuotedTable(`"`, `"`, suffix) }
+
+func (d RedshiftDialect) CreateSchemaSQL() string {
+	schema := d.QuotedSchema(`"`, `"`)
+	if schema == "" {
+		return ""
+	}
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", schema)
+}
+
+func (d RedshiftDialect) CreateVersionTableSQL() string {
 	return fmt.Sprintf(`CREATE TABLE %s (
             	id integer NOT NULL identity(1, 1),
                 version_id bigint NOT NULL,
                 is_applied boolean NOT NULL,
                 tstamp timestamp NULL default sysdate,
+                tags varchar(255) NULL,
                 PRIMARY KEY(id)
-            );`, d.TableName)
+            );`, d.table(""))
 }
 
-func (d RedshiftDialect) insertVersionSQL() string {
-	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, $2);", d.TableName)
+func (d RedshiftDialect) InsertVersionSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, $2);", d.table(""))
 }
 
-func (d RedshiftDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.TableName))
+func (d RedshiftDialect) DBVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.table("")))
 	if err != nil {
 		return nil, err
 	}
@@ -275,12 +662,45 @@ func (d RedshiftDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
 	return rows, err
 }
 
-func (d RedshiftDialect) migrationSQL() string {
-	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=$1 ORDER BY tstamp DESC LIMIT 1", d.TableName)
+func (d RedshiftDialect) MigrationSQL() string {
+	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=$1 ORDER BY tstamp DESC LIMIT 1", d.table(""))
+}
+
+func (d RedshiftDialect) DeleteVersionSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE version_id=$1;", d.table(""))
 }
 
-func (d RedshiftDialect) deleteVersionSQL() string {
-	return fmt.Sprintf("DELETE FROM %s WHERE version_id=$1;", d.TableName)
+func (d RedshiftDialect) CreateRepeatableTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+            	name varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default sysdate,
+                PRIMARY KEY(name)
+            );`, d.table(RepeatableTableSuffix))
+}
+
+func (d RedshiftDialect) InsertRepeatableSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (name, checksum) VALUES ($1, $2);", d.table(RepeatableTableSuffix))
+}
+
+func (d RedshiftDialect) DeleteRepeatableSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE name=$1;", d.table(RepeatableTableSuffix))
+}
+
+func (d RedshiftDialect) RepeatableQuerySQL() string {
+	return fmt.Sprintf("SELECT name, checksum, tstamp FROM %s", d.table(RepeatableTableSuffix))
+}
+
+func (d RedshiftDialect) AddTagsColumnSQL() string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN tags varchar(255) NULL;", d.table(""))
+}
+
+func (d RedshiftDialect) InsertVersionTagsSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tags) VALUES ($1, $2, $3);", d.table(""))
+}
+
+func (d RedshiftDialect) TagsQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, tags FROM %s WHERE tags IS NOT NULL ORDER BY id", d.table(""))
 }
 
 ////////////////////////////
@@ -290,22 +710,34 @@ func (d RedshiftDialect) deleteVersionSQL() string {
 // TiDBDialect struct.
 type TiDBDialect struct{ BaseDialect }
 
-func (d TiDBDialect) createVersionTableSQL() string {
+// table returns the quoted name of the version table, with the suffix, in its schema
+func (d TiDBDialect) table(suffix string) string { return d.QuotedTable("`", "`", suffix) }
+
+func (d TiDBDialect) CreateSchemaSQL() string {
+	schema := d.QuotedSchema("`", "`")
+	if schema == "" {
+		return ""
+	}
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", schema)
+}
+
+func (d TiDBDialect) CreateVersionTableSQL() string {
 	return fmt.Sprintf(`CREATE TABLE %s (
                 id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE,
                 version_id bigint NOT NULL,
                 is_applied boolean NOT NULL,
                 tstamp timestamp NULL default now(),
+                tags varchar(255) NULL,
                 PRIMARY KEY(id)
-            );`, d.TableName)
+            );`, d.table(""))
 }
 
-func (d TiDBDialect) insertVersionSQL() string {
-	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (?, ?);", d.TableName)
+func (d TiDBDialect) InsertVersionSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (?, ?);", d.table(""))
 }
 
-func (d TiDBDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.TableName))
+func (d TiDBDialect) DBVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied from %s ORDER BY id DESC", d.table("")))
 	if err != nil {
 		return nil, err
 	}
@@ -313,46 +745,177 @@ func (d TiDBDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
 	return rows, err
 }
 
-func (d TiDBDialect) migrationSQL() string {
-	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1", d.TableName)
+func (d TiDBDialect) MigrationSQL() string {
+	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1", d.table(""))
+}
+
+func (d TiDBDialect) DeleteVersionSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE version_id=?;", d.table(""))
+}
+
+func (d TiDBDialect) CreateRepeatableTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                name varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(name)
+            );`, d.table(RepeatableTableSuffix))
+}
+
+func (d TiDBDialect) InsertRepeatableSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (name, checksum) VALUES (?, ?);", d.table(RepeatableTableSuffix))
+}
+
+func (d TiDBDialect) DeleteRepeatableSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE name=?;", d.table(RepeatableTableSuffix))
 }
 
-func (d TiDBDialect) deleteVersionSQL() string {
-	return fmt.Sprintf("DELETE FROM %s WHERE version_id=?;", d.TableName)
+func (d TiDBDialect) RepeatableQuerySQL() string {
+	return fmt.Sprintf("SELECT name, checksum, tstamp FROM %s", d.table(RepeatableTableSuffix))
+}
+
+func (d TiDBDialect) AddTagsColumnSQL() string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN tags varchar(255) NULL;", d.table(""))
+}
+
+func (d TiDBDialect) InsertVersionTagsSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tags) VALUES (?, ?, ?);", d.table(""))
+}
+
+func (d TiDBDialect) TagsQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, tags FROM %s WHERE tags IS NOT NULL ORDER BY id", d.table(""))
 }
 
 ////////////////////////////
 // ClickHouse
 ////////////////////////////
 
-// ClickHouseDialect struct.
-type ClickHouseDialect struct{ BaseDialect }
+// ClickHouseDialect struct. The version table is created with the MergeTree engine, or ReplicatedMergeTree
+// when a Cluster is set, and ordered by the nanosecond id it is inserted with.
+type ClickHouseDialect struct {
+	BaseDialect
+	// Cluster, if set, is the cluster the tables of goose are created on, with ON CLUSTER
+	Cluster string
+	// Engine is the engine of the tables of goose, e.g. ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}').
+	// By default it is MergeTree, or ReplicatedMergeTree if Cluster is set.
+	Engine string
+	// Settings are the settings of the tables of goose, e.g. index_granularity = 8192
+	Settings string
+	// LightweightDelete deletes the versions with a lightweight DELETE FROM, instead of an ALTER TABLE
+	// ... DELETE mutation. Either way the delete is synchronous.
+	LightweightDelete bool
+}
+
+// table returns the quoted name of the version table, with the suffix, in its schema
+func (d ClickHouseDialect) table(suffix string) string { return d.QuotedTable("`", "`", suffix) }
+
+// onCluster returns the ON CLUSTER clause of the DDL, if a Cluster is set
+func (d ClickHouseDialect) onCluster() string {
+	if d.Cluster == "" {
+		return ""
+	}
+	return " ON CLUSTER " + QuoteIdent("`", "`", d.Cluster)
+}
+
+// createTableSQL returns the DDL creating the table, with the columns, ordered by orderBy
+func (d ClickHouseDialect) createTableSQL(table, columns, orderBy string) string {
+	engine := d.Engine
+	switch {
+	case engine != "":
+	case d.Cluster != "":
+		engine = "ReplicatedMergeTree()"
+	default:
+		engine = "MergeTree()"
+	}
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s%s (%s
+    ) ENGINE = %s ORDER BY %s`, table, d.onCluster(), columns, engine, orderBy)
+	if d.Settings != "" {
+		ddl += " SETTINGS " + d.Settings
+	}
+	return ddl
+}
+
+// deleteSQL returns the statement deleting the rows of the table where column is the first parameter,
+// it only returns once the rows are deleted on all the replicas.
+func (d ClickHouseDialect) deleteSQL(table, column string) string {
+	if d.LightweightDelete {
+		// lightweight deletes are synchronous by default
+		return fmt.Sprintf("DELETE FROM %s%s WHERE %s = $1", table, d.onCluster(), column)
+	}
+	return fmt.Sprintf("ALTER TABLE %s%s DELETE WHERE %s = $1 SETTINGS mutations_sync = 2", table, d.onCluster(), column)
+}
+
+func (d ClickHouseDialect) CreateSchemaSQL() string {
+	schema := d.QuotedSchema("`", "`")
+	if schema == "" {
+		return ""
+	}
+	return fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s%s", schema, d.onCluster())
+}
 
-func (d ClickHouseDialect) createVersionTableSQL() string {
-	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+func (d ClickHouseDialect) CreateVersionTableSQL() string {
+	return d.createTableSQL(d.table(""), `
+      id Int64 default toUnixTimestamp64Nano(now64(9)),
       version_id Int64,
       is_applied UInt8,
+      tags Nullable(String),
       date Date default now(),
-      tstamp DateTime default now()
-    ) Engine = MergeTree(date, (date), 8192)`, d.TableName)
+      tstamp DateTime default now()`, "id")
 }
 
-func (d ClickHouseDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY tstamp DESC", d.TableName))
-	if err != nil {
+func (d ClickHouseDialect) DBVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	var count int64
+	if err := db.QueryRow(d.ColumnExistsSQL("id")).Scan(&count); err != nil {
 		return nil, err
 	}
-	return rows, err
+	if count == 0 {
+		// version tables created before the id column was added are ordered by the second they were
+		// applied in, and then by version
+		return db.Query(fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY tstamp DESC, version_id DESC", d.table("")))
+	}
+	return db.Query(fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY id DESC", d.table("")))
+}
+
+func (d ClickHouseDialect) InsertVersionSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, $2)", d.table(""))
+}
+
+func (d ClickHouseDialect) MigrationSQL() string {
+	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id = $1 ORDER BY id DESC LIMIT 1", d.table(""))
+}
+
+func (d ClickHouseDialect) DeleteVersionSQL() string {
+	return d.deleteSQL(d.table(""), "version_id")
+}
+
+func (d ClickHouseDialect) CreateRepeatableTableSQL() string {
+	return d.createTableSQL(d.table(RepeatableTableSuffix), `
+      name String,
+      checksum String,
+      date Date default now(),
+      tstamp DateTime default now()`, "name")
+}
+
+func (d ClickHouseDialect) InsertRepeatableSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (name, checksum) VALUES ($1, $2)", d.table(RepeatableTableSuffix))
+}
+
+func (d ClickHouseDialect) DeleteRepeatableSQL() string {
+	return d.deleteSQL(d.table(RepeatableTableSuffix), "name")
+}
+
+func (d ClickHouseDialect) RepeatableQuerySQL() string {
+	return fmt.Sprintf("SELECT name, checksum, tstamp FROM %s", d.table(RepeatableTableSuffix))
 }
 
-func (d ClickHouseDialect) insertVersionSQL() string {
-	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, $2)", d.TableName)
+func (d ClickHouseDialect) AddTagsColumnSQL() string {
+	return fmt.Sprintf("ALTER TABLE %s%s ADD COLUMN IF NOT EXISTS tags Nullable(String)", d.table(""), d.onCluster())
 }
 
-func (d ClickHouseDialect) migrationSQL() string {
-	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id = $1 ORDER BY tstamp DESC LIMIT 1", d.TableName)
+func (d ClickHouseDialect) InsertVersionTagsSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tags) VALUES ($1, $2, $3)", d.table(""))
 }
 
-func (d ClickHouseDialect) deleteVersionSQL() string {
-	return fmt.Sprintf("ALTER TABLE %s DELETE WHERE version_id = $1", d.TableName)
+func (d ClickHouseDialect) TagsQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, tags FROM %s WHERE tags IS NOT NULL ORDER BY id", d.table(""))
 }
@@ -0,0 +1,71 @@
+package goose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClickHouseDialect(t *testing.T) {
+	t.Parallel()
+	d := &ClickHouseDialect{BaseDialect: BaseDialect{TableName: "goose_db_version"}}
+	for name, check := range map[string]struct{ got, contains string }{
+		"engine":    {d.CreateVersionTableSQL(), ") ENGINE = MergeTree() ORDER BY id"},
+		"id":        {d.CreateVersionTableSQL(), "id Int64 default toUnixTimestamp64Nano(now64(9))"},
+		"delete":    {d.DeleteVersionSQL(), "ALTER TABLE goose_db_version DELETE WHERE version_id = $1 SETTINGS mutations_sync = 2"},
+		"migration": {d.MigrationSQL(), "WHERE version_id = $1 ORDER BY id DESC LIMIT 1"},
+		"id column": {d.ColumnExistsSQL("id"), "FROM system.columns WHERE database = currentDatabase() AND table = 'goose_db_version' AND name = 'id'"},
+		"no schema": {d.CreateSchemaSQL() + "none", "none"},
+	} {
+		if !strings.Contains(check.got, check.contains) {
+			t.Errorf("%s, got %q expected it to contain %q", name, check.got, check.contains)
+		}
+	}
+
+	d.Cluster = "main"
+	d.Settings = "index_granularity = 8192"
+	d.LightweightDelete = true
+	d.SetSchema("app", true)
+	d.SetQuoteIdentifiers(true)
+	for name, check := range map[string]struct{ got, contains string }{
+		"schema":     {d.CreateSchemaSQL(), "CREATE DATABASE IF NOT EXISTS `app` ON CLUSTER `main`"},
+		"cluster":    {d.CreateVersionTableSQL(), "CREATE TABLE IF NOT EXISTS `app`.`goose_db_version` ON CLUSTER `main` ("},
+		"replicated": {d.CreateRepeatableTableSQL(), ") ENGINE = ReplicatedMergeTree() ORDER BY name SETTINGS index_granularity = 8192"},
+		"tags":       {d.AddTagsColumnSQL(), "ALTER TABLE `app`.`goose_db_version` ON CLUSTER `main` ADD COLUMN IF NOT EXISTS tags Nullable(String)"},
+		"delete":     {d.DeleteRepeatableSQL(), "DELETE FROM `app`.`goose_db_version_repeatable` ON CLUSTER `main` WHERE name = $1"},
+	} {
+		if !strings.Contains(check.got, check.contains) {
+			t.Errorf("%s, got %q expected it to contain %q", name, check.got, check.contains)
+		}
+	}
+
+	d.Engine = "ReplacingMergeTree"
+	if got := d.CreateVersionTableSQL(); !strings.Contains(got, "ENGINE = ReplacingMergeTree ORDER BY id") {
+		t.Errorf("engine, got %q expected the ReplacingMergeTree engine", got)
+	}
+}
+
+func TestTableExistsSQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		dialect  TableExistsDialect
+		expected string
+	}{
+		{&PostgresDialect{BaseDialect{TableName: "Goose_DB_Version"}},
+			"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'goose_db_version_history'"},
+		{&PostgresDialect{BaseDialect{TableName: `app."Goose"`}},
+			"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'app' AND table_name = 'Goose_history'"},
+		{&MySQLDialect{BaseDialect{TableName: "goose_db_version", Schema: "app"}},
+			"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'app' AND table_name = 'goose_db_version_history'"},
+		{&SqlServerDialect{BaseDialect{TableName: "goose_db_version"}},
+			"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = SCHEMA_NAME() AND table_name = 'goose_db_version_history'"},
+		{&Sqlite3Dialect{BaseDialect{TableName: "aux.goose_db_version"}},
+			`SELECT COUNT(*) FROM aux.sqlite_master WHERE type = 'table' AND name = 'goose_db_version_history' COLLATE NOCASE`},
+		{&ClickHouseDialect{BaseDialect: BaseDialect{TableName: "goose_db_version"}},
+			"SELECT COUNT(*) FROM system.tables WHERE database = currentDatabase() AND name = 'goose_db_version_history'"},
+	}
+	for _, tc := range tests {
+		if got := tc.dialect.TableExistsSQL(HistoryTableSuffix); got != tc.expected {
+			t.Errorf("%T, got %q expected %q", tc.dialect, got, tc.expected)
+		}
+	}
+}
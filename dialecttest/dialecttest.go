@@ -0,0 +1,211 @@
+// Package dialecttest checks that a goose.SQLDialect works with its database. A dialect added with
+// goose.RegisterDialect can run the checks from its own tests:
+//
+//	func TestDialect(t *testing.T) {
+//		db, err := sql.Open("duckdb", "")
+//		...
+//		dialecttest.Run(t, db, newDuckDBDialect)
+//	}
+package dialecttest
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gdey/goose/v3"
+)
+
+// TableName is the version table the checks use, it and the tables next to it are dropped
+// before and after the checks.
+const TableName = "goose_dialecttest"
+
+// migrations are three Go migrations without functions, and a repeatable migration, so only
+// the statements of the dialect are run.
+var migrations = fstest.MapFS{
+	"migrations/00001_a.go":       {Data: []byte("package migrations\n")},
+	"migrations/00002_b.go":       {Data: []byte("package migrations\n")},
+	"migrations/00003_c.go":       {Data: []byte("package migrations\n")},
+	"migrations/R_repeatable.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+}
+
+// Run runs the checks of the dialect returned by newDialect against db, each as a subtest of t.
+func Run(t *testing.T, db *sql.DB, newDialect goose.DialectFactory) {
+	t.Helper()
+	dropTables(t, db)
+	t.Cleanup(func() { dropTables(t, db) })
+
+	p, err := goose.NewProviderE(
+		goose.DialectObject(newDialect(TableName)),
+		goose.Tablename(TableName),
+		goose.Filesystem(migrations),
+		goose.Log(testLogger{t}),
+	)
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	for _, name := range []string{"00001_a.go", "00002_b.go", "00003_c.go"} {
+		if err := p.AddNamedMigrationWithOptionsE("migrations/"+name, nil, nil, goose.MigrationTags("dialecttest")); err != nil {
+			t.Fatalf("register %s: %v", name, err)
+		}
+	}
+
+	// the checks depend on each other, they stop at the first that fails
+	checks := []struct {
+		name  string
+		check func(t *testing.T, p *goose.Provider, db *sql.DB)
+	}{
+		{"version table", checkVersionTable},
+		{"up", checkUp},
+		{"status", checkStatus},
+		{"down", checkDown},
+		{"history", checkHistory},
+		{"reset", checkReset},
+	}
+	for _, c := range checks {
+		if !t.Run(c.name, func(t *testing.T) { c.check(t, p, db) }) {
+			return
+		}
+	}
+}
+
+// checkVersionTable checks the version table is created, and the database is at version 0. If the dialect
+// can ask the database if a table exists, the version table is found and a table that does not exist is not.
+func checkVersionTable(t *testing.T, p *goose.Provider, db *sql.DB) {
+	if _, err := p.EnsureDBVersion(db); err != nil {
+		t.Fatalf("create version table: %v", err)
+	}
+	expectVersion(t, p, db, 0)
+	if td, ok := p.Dialect().(goose.TableExistsDialect); ok {
+		expectCount(t, db, td.TableExistsSQL(""), 1)
+		expectCount(t, db, td.TableExistsSQL("_missing"), 0)
+	}
+}
+
+// checkUp checks the migrations are recorded, with their tags in the version table, and the repeatable
+// migration's checksum
+func checkUp(t *testing.T, p *goose.Provider, db *sql.DB) {
+	if err := p.Up(db, "migrations", goose.WithNoOutput(), goose.WithHistory(), goose.WithAppliedBy("dialecttest"), goose.WithTags("dialecttest")); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	expectVersion(t, p, db, 3)
+	expectRows(t, db, p.Dialect().RepeatableQuerySQL(), 1)
+	expectRows(t, db, p.Dialect().TagsQuerySQL(), 3)
+	if td, ok := p.Dialect().(goose.TableExistsDialect); ok {
+		expectCount(t, db, td.ColumnExistsSQL("tags"), 1)
+		expectCount(t, db, td.ColumnExistsSQL("missing"), 0)
+	}
+}
+
+// checkStatus checks the time each migration, and the repeatable migration, was applied is read back
+func checkStatus(t *testing.T, p *goose.Provider, db *sql.DB) {
+	events := make(chan goose.Eventer, 10)
+	if err := p.Status(db, "migrations", goose.WithNoOutput(), goose.WithEvents(events, false)); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	applied, repeatable := 0, 0
+	for e := range events {
+		se, ok := e.(goose.StatusEvent)
+		switch {
+		case !ok || se.AppliedAt.IsZero():
+		case se.Repeatable:
+			repeatable++
+		default:
+			applied++
+		}
+	}
+	if applied != 3 || repeatable != 1 {
+		t.Errorf("status, got %d applied migrations and %d repeatable expected 3 and 1", applied, repeatable)
+	}
+}
+
+// checkDown checks the version of a rolled back migration is deleted
+func checkDown(t *testing.T, p *goose.Provider, db *sql.DB) {
+	if err := p.Down(db, "migrations", goose.WithNoOutput(), goose.WithHistory()); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	expectVersion(t, p, db, 2)
+	if err := p.UpByOne(db, "migrations", goose.WithNoOutput(), goose.WithHistory(), goose.WithAppliedBy("dialecttest")); err != nil {
+		t.Fatalf("up-by-one: %v", err)
+	}
+	expectVersion(t, p, db, 3)
+}
+
+// checkReset checks all the migrations are rolled back
+func checkReset(t *testing.T, p *goose.Provider, db *sql.DB) {
+	if err := p.Reset(db, "migrations", goose.WithNoOutput(), goose.WithHistory()); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	expectVersion(t, p, db, 0)
+}
+
+// checkHistory checks the rows of the version table are read back in order, with who applied the migrations
+// recorded with WithHistory
+func checkHistory(t *testing.T, p *goose.Provider, db *sql.DB) {
+	if _, ok := p.Dialect().(goose.HistoryDialect); !ok {
+		t.Skip("the dialect does not record the history")
+	}
+	entries, err := p.History(db)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, fmt.Sprintf("%d %s %s", e.Version, e.Direction, e.AppliedBy))
+	}
+	expected := []string{"1 up dialecttest", "2 up dialecttest", "3 up dialecttest"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("history, got %v expected %v", got, expected)
+	}
+}
+
+func expectVersion(t *testing.T, p *goose.Provider, db *sql.DB, expected int64) {
+	t.Helper()
+	version, err := p.GetDBVersion(db)
+	if err != nil || version != expected {
+		t.Fatalf("version, got %d, %v expected %d", version, err, expected)
+	}
+}
+
+func expectCount(t *testing.T, db *sql.DB, query string, expected int64) {
+	t.Helper()
+	var count int64
+	if err := db.QueryRow(query).Scan(&count); err != nil || count != expected {
+		t.Fatalf("%s, got %d, %v expected %d", query, count, err, expected)
+	}
+}
+
+func expectRows(t *testing.T, db *sql.DB, query string, expected int) {
+	t.Helper()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if err := rows.Err(); err != nil || count != expected {
+		t.Fatalf("%s, got %d rows, %v expected %d", query, count, err, expected)
+	}
+}
+
+// dropTables drops the tables of the checks, the errors for the tables that do not exist are ignored
+func dropTables(t *testing.T, db *sql.DB) {
+	for _, table := range []string{TableName, TableName + goose.RepeatableTableSuffix, TableName + goose.HistoryTableSuffix} {
+		if _, err := db.Exec(fmt.Sprintf("DROP TABLE %s", table)); err == nil {
+			t.Logf("dropped table %s", table)
+		}
+	}
+}
+
+// testLogger logs the output of the goose commands to the test log
+type testLogger struct{ t *testing.T }
+
+func (l testLogger) Fatal(v ...interface{})                 { l.t.Fatal(v...) }
+func (l testLogger) Fatalf(format string, v ...interface{}) { l.t.Fatalf(format, v...) }
+func (l testLogger) Print(v ...interface{})                 { l.t.Log(v...) }
+func (l testLogger) Println(v ...interface{})               { l.t.Log(v...) }
+func (l testLogger) Printf(format string, v ...interface{}) { l.t.Logf(format, v...) }
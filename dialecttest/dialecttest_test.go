@@ -0,0 +1,69 @@
+package dialecttest_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdey/goose/v3"
+	"github.com/gdey/goose/v3/dialecttest"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDialect is the sqlite3 dialect, as a third-party dialect would be registered
+type sqliteDialect struct{ goose.Sqlite3Dialect }
+
+func init() {
+	goose.RegisterDialect("dialecttest-sqlite", func(tableName string) goose.SQLDialect {
+		d := &sqliteDialect{}
+		d.SetTableName(tableName)
+		return d
+	})
+}
+
+func TestRun(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "dialecttest.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	dialecttest.Run(t, db, func(tableName string) goose.SQLDialect {
+		d, err := goose.SelectDialect(tableName, "dialecttest-sqlite")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	})
+}
+
+func TestRegisterDialect(t *testing.T) {
+	found := false
+	for _, name := range goose.Dialects() {
+		found = found || name == "dialecttest-sqlite"
+	}
+	if !found {
+		t.Errorf("dialects, got %v expected dialecttest-sqlite to be registered", goose.Dialects())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a dialect twice, expected a panic")
+		}
+	}()
+	goose.RegisterDialect(goose.DialectPostgres, func(string) goose.SQLDialect { return nil })
+}
+
+func TestRunGeneric(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "generic.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	factory, err := goose.GenericDialectFactory(goose.GenericConfig{
+		Placeholder:   goose.PlaceholderQuestion,
+		AutoIncrement: "INTEGER PRIMARY KEY AUTOINCREMENT",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialecttest.Run(t, db, factory)
+}
@@ -2,8 +2,8 @@ package goose
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"time"
 )
 
 // Down rolls back a single migration from the current version.
@@ -12,30 +12,37 @@ func Down(db *sql.DB, dir string, opts ...OptionsFunc) error {
 }
 
 // Down rolls back a single migration from the current version.
-func (p *Provider) Down(db *sql.DB, dir string, opts ...OptionsFunc) error {
-	option := applyOptions(opts)
+func (p *Provider) Down(db *sql.DB, dir string, opts ...OptionsFunc) (err error) {
+	option := p.newRun(opts)
 	if option.shouldCloseEventsChannel() {
 		defer close(option.eventsChannel)
 	}
+	if err := option.checkReadOnly("down"); err != nil {
+		return err
+	}
+	defer func() { err = p.finishCommand(db, option, "down", true, err) }()
 	migrations, err := p.CollectMigrations(dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
+	if option.callbacks, err = p.collectSQLCallbacks(p.baseFS, dir); err != nil {
+		return err
+	}
 	if option.noVersioning {
 		if len(migrations) == 0 {
 			return nil
 		}
 		currentVersion := migrations[len(migrations)-1].Version
 		// Migrate only the latest migration down.
-		return downToNoVersioning(p, db, migrations, currentVersion-1, opts...)
+		return downToNoVersioning(p, db, migrations, currentVersion-1, option)
 	}
 	currentVersion, err := p.GetDBVersion(db)
 	if err != nil {
 		return err
 	}
-	current, err := migrations.Current(currentVersion)
+	current, err := currentMigration(migrations, currentVersion)
 	if err != nil {
-		return fmt.Errorf("no migration %v", currentVersion)
+		return err
 	}
 	previous, err := migrations.Previous(currentVersion)
 	if err != nil {
@@ -46,31 +53,14 @@ func (p *Provider) Down(db *sql.DB, dir string, opts ...OptionsFunc) error {
 		VersionSource:     current.Source,
 		TotalVersionsLeft: 1,
 	})
-	option.send(VersionApplyEvent{
+	return p.applyMigration(db, current, VersionApplyEvent{
 		From:       current.Version,
 		FromSource: current.Source,
 		To:         previous.Version,
 		ToSource:   previous.Source,
-		ApplyAT:    time.Now(),
-		Applied:    false,
 		Down:       true,
 		Versioned:  true,
-	})
-	err = current.DownWithProvider(p, db)
-	if err != nil {
-		return err
-	}
-	option.send(VersionApplyEvent{
-		From:       current.Version,
-		FromSource: current.Source,
-		To:         previous.Version,
-		ToSource:   previous.Source,
-		ApplyAT:    time.Now(),
-		Applied:    true,
-		Down:       true,
-		Versioned:  true,
-	})
-	return nil
+	}, option)
 }
 
 // DownTo rolls back migrations to a specific version.
@@ -78,20 +68,33 @@ func DownTo(db *sql.DB, dir string, version int64, opts ...OptionsFunc) error {
 	return defaultProvider.DownTo(db, dir, version, opts...)
 }
 
-// DownTo rolls back migrations to a specific version.
-func (p *Provider) DownTo(db *sql.DB, dir string, version int64, opts ...OptionsFunc) error {
-	option := applyOptions(opts)
+// DownTo rolls back migrations to a specific version. Rolled back to version 0 the checksums of the
+// repeatable migrations are deleted, as Reset does.
+func (p *Provider) DownTo(db *sql.DB, dir string, version int64, opts ...OptionsFunc) (err error) {
+	option := p.newRun(opts)
 	if option.shouldCloseEventsChannel() {
-		close(option.eventsChannel)
+		defer close(option.eventsChannel)
+	}
+	if err := option.checkReadOnly("down-to"); err != nil {
+		return err
 	}
+	defer func() { err = p.finishCommand(db, option, "down-to", true, err) }()
 	migrations, err := p.CollectMigrations(dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
+	if option.callbacks, err = p.collectSQLCallbacks(p.baseFS, dir); err != nil {
+		return err
+	}
 	if option.noVersioning {
-		return downToNoVersioning(p, db, migrations, version, opts...)
+		return downToNoVersioning(p, db, migrations, version, option)
+	}
+	// with dependencies only the migrations depending on the version are rolled back
+	if version > 0 && migrations.hasRequires() {
+		return p.downGraph(db, migrations, version, option)
 	}
 
+	sentCount := false
 	for {
 		currentVersion, err := p.GetDBVersion(db)
 		if err != nil {
@@ -99,12 +102,22 @@ func (p *Provider) DownTo(db *sql.DB, dir string, version int64, opts ...Options
 		}
 
 		if currentVersion == 0 {
+			if !option.noOutput {
+				p.log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
+			}
+			// once all the migrations are rolled back the repeatable migrations are applied again by up
+			if version == 0 {
+				return p.clearRepeatable(db)
+			}
+			return nil
+		}
+		if currentVersion <= version {
 			if !option.noOutput {
 				p.log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
 			}
 			return nil
 		}
-		current, err := migrations.Current(currentVersion)
+		current, err := currentMigration(migrations, currentVersion)
 		if err != nil {
 			if !option.noOutput {
 				p.log.Printf("goose: migration file not found for current version (%d), error: %s\n", currentVersion, err)
@@ -112,26 +125,58 @@ func (p *Provider) DownTo(db *sql.DB, dir string, version int64, opts ...Options
 			return err
 		}
 
-		if current.Version <= version {
-			if !option.noOutput {
-				p.log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
+		if !sentCount {
+			sentCount = true
+			total := 0
+			for _, m := range migrations {
+				if m.Version > version && m.Version <= current.Version {
+					total++
+				}
 			}
-			return nil
+			option.send(VersionCountEvent{
+				Version:           current.Version,
+				VersionSource:     current.Source,
+				TotalVersionsLeft: total,
+			})
 		}
 
-		if err = current.DownWithProvider(p, db); err != nil {
+		apply := VersionApplyEvent{
+			From:       current.Version,
+			FromSource: current.Source,
+			Down:       true,
+			Versioned:  true,
+		}
+		if previous, err := migrations.Previous(current.Version); err == nil {
+			apply.To = previous.Version
+			apply.ToSource = previous.Source
+		}
+		if err = p.applyMigration(db, current, apply, option); err != nil {
 			return err
 		}
 	}
 }
 
+// currentMigration returns the migration of the version the database is at. At version 0 no migration is
+// applied, any other version without a migration is orphaned.
+func currentMigration(migrations Migrations, version int64) (*Migration, error) {
+	current, err := migrations.Current(version)
+	switch {
+	case err == nil:
+		return current, nil
+	case version == 0:
+		return nil, fmt.Errorf("no migration %v", version)
+	case errors.Is(err, ErrNoCurrentVersion):
+		return nil, ErrOrphanedVersions{Versions: []int64{version}}
+	}
+	return nil, err
+}
+
 // downToNoVersioning applies down migrations down to, but not including, the
 // target version.
-func downToNoVersioning(p *Provider, db *sql.DB, migrations Migrations, version int64, opts ...OptionsFunc) error {
+func downToNoVersioning(p *Provider, db *sql.DB, migrations Migrations, version int64, option *runState) error {
 	if p == nil {
 		p = defaultProvider
 	}
-	option := applyOptions(opts)
 	ver, err := migrations.Last()
 	if err != nil {
 		// There are not versions to migrate down to, so just return
@@ -148,10 +193,14 @@ func downToNoVersioning(p *Provider, db *sql.DB, migrations Migrations, version
 		}
 	}
 
+	total, err := migrations.NumberOfMigrationsToE(ver.Version, finalVersion)
+	if err != nil {
+		return err
+	}
 	option.send(VersionCountEvent{
 		Version:           ver.Version,
 		VersionSource:     ver.Source,
-		TotalVersionsLeft: migrations.NumberOfMigrationsTo(ver.Version, finalVersion),
+		TotalVersionsLeft: total,
 	})
 
 	for i := len(migrations) - 1; i >= finalI; i-- {
@@ -162,27 +211,15 @@ func downToNoVersioning(p *Provider, db *sql.DB, migrations Migrations, version
 			preS = migrations[i-1].Source
 		}
 		migrations[i].noVersioning = true
-		option.send(VersionApplyEvent{
+		if err := p.applyMigration(db, migrations[i], VersionApplyEvent{
 			From:       migrations[i].Version,
 			FromSource: migrations[i].Source,
 			To:         preV,
 			ToSource:   preS,
-			ApplyAT:    time.Now(),
-			Applied:    false,
 			Down:       true,
-		})
-		if err := migrations[i].DownWithProvider(p, db); err != nil {
+		}, option); err != nil {
 			return err
 		}
-		option.send(VersionApplyEvent{
-			From:       migrations[i].Version,
-			FromSource: migrations[i].Source,
-			To:         preV,
-			ToSource:   preS,
-			ApplyAT:    time.Now(),
-			Applied:    true,
-			Down:       true,
-		})
 	}
 	if !option.noOutput {
 		p.log.Printf("goose: down to current file version: %d\n", finalVersion)
@@ -2,6 +2,7 @@ package goose
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 )
 
@@ -71,3 +72,257 @@ type ErrTimestampVersionsExist struct {
 func (err ErrTimestampVersionsExist) Error() string {
 	return "Timestamp migrations exists"
 }
+
+type ErrFixConflict struct {
+	Rename FixRename
+	Reason string
+}
+
+func (err ErrFixConflict) Error() string {
+	var str strings.Builder
+	str.WriteString("cannot fix ")
+	str.WriteString(err.Rename.String())
+	str.WriteString(": ")
+	str.WriteString(err.Reason)
+	return str.String()
+}
+
+// ErrSQLParseLine is returned by the SQL parser, Line is the line in the file the error was found on.
+type ErrSQLParseLine struct {
+	Line int
+
+	ErrUnwrap
+}
+
+func (err ErrSQLParseLine) Error() string {
+	return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+}
+
+// ErrUnknownDialect is returned when a dialect name is not one of the registered dialects.
+type ErrUnknownDialect struct {
+	Dialect string
+}
+
+func (err ErrUnknownDialect) Error() string {
+	return fmt.Sprintf("%q: unknown dialect", err.Dialect)
+}
+
+// ErrDuplicateVersion is returned when more than one migration has the same version.
+type ErrDuplicateVersion struct {
+	Version int64
+	Sources []string
+}
+
+func (err ErrDuplicateVersion) Error() string {
+	var str strings.Builder
+	fmt.Fprintf(&str, "goose: duplicate version %v detected:", err.Version)
+	for _, src := range err.Sources {
+		str.WriteString("\n")
+		str.WriteString(src)
+	}
+	return str.String()
+}
+
+// ErrVersionNotFound is returned when a version is not in the set of migrations.
+type ErrVersionNotFound struct {
+	Version int64
+}
+
+func (err ErrVersionNotFound) Error() string {
+	return fmt.Sprintf("did not find version %v", err.Version)
+}
+
+// ErrMigrationFilename is returned when a migration's file name does not have a valid version.
+type ErrMigrationFilename struct {
+	Filename string
+
+	ErrUnwrap
+}
+
+func (err ErrMigrationFilename) Error() string {
+	return fmt.Sprintf("failed to add migration %q: %v", err.Filename, err.Err)
+}
+
+// MigrationError is returned when a migration fails to run. It wraps the error returned
+// by the driver, or the error that caused the failure.
+type MigrationError struct {
+	Version   int64
+	Source    string
+	Direction Direction
+	// StatementIndex is the index of the statement that failed, or -1 if the failure was not
+	// caused by one of the migration's statements
+	StatementIndex int
+	// Statement is the statement that failed, with comments removed
+	Statement string
+	// Line is the line in Source the failed statement starts on, zero if unknown
+	Line int
+	// InTransaction is true if the migration was being run in a transaction
+	InTransaction bool
+	// RolledBack is true if the transaction was successfully rolled back
+	RolledBack bool
+	// ImplicitCommit is true if the database committed the transaction implicitly on a DDL statement,
+	// see DialectCapabilities.TransactionalDDL, so the migration was only partially rolled back
+	ImplicitCommit bool
+
+	ErrUnwrap
+}
+
+func (err MigrationError) Error() string {
+	var str strings.Builder
+	fmt.Fprintf(&str, "ERROR %v: failed to run %s migration", filepath.Base(err.Source), err.Direction)
+	if err.StatementIndex >= 0 {
+		fmt.Fprintf(&str, ": statement %d", err.StatementIndex)
+		if err.Line > 0 {
+			fmt.Fprintf(&str, " (line %d)", err.Line)
+		}
+		if err.Statement != "" {
+			fmt.Fprintf(&str, " %q", err.Statement)
+		}
+	}
+	if err.InTransaction {
+		switch {
+		case err.RolledBack:
+			str.WriteString(", rolled back")
+		case err.ImplicitCommit:
+			str.WriteString(", not rolled back: DDL was committed implicitly")
+		default:
+			str.WriteString(", not rolled back")
+		}
+	}
+	str.WriteString(": ")
+	str.WriteString(err.Err.Error())
+	return str.String()
+}
+
+// ErrHook is returned when one of the provider's migration hooks fails
+type ErrHook struct {
+	// Hook is the kind of hook that failed, e.g. BeforeEach
+	Hook string
+	// Committed is set if the hook failed after the migration in Source was committed, the migration is applied
+	Committed bool
+	Source    string
+	ErrUnwrap
+}
+
+func (err ErrHook) Error() string {
+	if err.Committed {
+		return fmt.Sprintf("%s hook failed after %s was committed: %v", err.Hook, err.Source, err.Err)
+	}
+	return fmt.Sprintf("%s hook failed: %v", err.Hook, err.Err)
+}
+
+// ErrSeedSchemaVersion is returned when a seed needs a newer schema version than the database is at
+type ErrSeedSchemaVersion struct {
+	// Source is the seed file
+	Source string
+	// Required is the schema version the seed declares it needs, Current the version the database is at
+	Required, Current int64
+}
+
+func (err ErrSeedSchemaVersion) Error() string {
+	return fmt.Sprintf("seed %v requires schema version %d, database is at version %d",
+		filepath.Base(err.Source), err.Required, err.Current)
+}
+
+// ErrTarget is the error a command run by a FanOut failed with for a target
+type ErrTarget struct {
+	Target string
+	ErrUnwrap
+}
+
+func (err ErrTarget) Error() string {
+	return fmt.Sprintf("target %s: %v", err.Target, err.Err)
+}
+
+// ErrTargetDialect is returned for the target of a FanOut whose driver is of another dialect than the
+// provider running the command.
+type ErrTargetDialect struct {
+	Driver string
+}
+
+func (err ErrTargetDialect) Error() string {
+	return fmt.Sprintf("the %s driver is of another dialect than the provider's", err.Driver)
+}
+
+// ErrGroupDependency is returned when a provider of a ProviderGroup is to run after a provider that is not
+// in the group, or the providers in Cycle are to run after each other.
+type ErrGroupDependency struct {
+	Provider   string
+	Dependency string
+	Cycle      []string
+}
+
+func (err ErrGroupDependency) Error() string {
+	if len(err.Cycle) > 0 {
+		return fmt.Sprintf("provider group: providers %s depend on each other", strings.Join(err.Cycle, ", "))
+	}
+	return fmt.Sprintf("provider group: provider %s runs after unknown provider %s", err.Provider, err.Dependency)
+}
+
+// ErrGroupProvider is the error a command of a ProviderGroup failed with for one of its providers
+type ErrGroupProvider struct {
+	Provider string
+	ErrUnwrap
+}
+
+func (err ErrGroupProvider) Error() string {
+	return fmt.Sprintf("provider %s: %v", err.Provider, err.Err)
+}
+
+// ErrMigrationDependency is returned when a migration requires a version that is neither applied nor
+// available, or the migrations in Cycle require each other.
+type ErrMigrationDependency struct {
+	Version    int64
+	Source     string
+	Dependency int64
+	Cycle      []int64
+}
+
+func (err ErrMigrationDependency) Error() string {
+	if len(err.Cycle) > 0 {
+		versions := make([]string, len(err.Cycle))
+		for i, v := range err.Cycle {
+			versions[i] = fmt.Sprint(v)
+		}
+		return fmt.Sprintf("migrations %s require each other", strings.Join(versions, ", "))
+	}
+	return fmt.Sprintf("migration %s requires version %d, which is neither applied nor available",
+		filepath.Base(err.Source), err.Dependency)
+}
+
+// ErrNotInitialized is returned by the commands run read-only, see WithReadOnly, when the version table
+// can not be queried, usually because it does not exist.
+type ErrNotInitialized struct {
+	Table string
+	ErrUnwrap
+}
+
+func (err ErrNotInitialized) Error() string {
+	return fmt.Sprintf("database not initialized, failed to query version table %s: %v", err.Table, err.Err)
+}
+
+// ErrReadOnly is returned by the commands that change the database when they are run read-only, see WithReadOnly.
+type ErrReadOnly struct {
+	Command string
+}
+
+func (err ErrReadOnly) Error() string {
+	return fmt.Sprintf("%s changes the database, it can not be run read-only", err.Command)
+}
+
+// ErrOrphanedVersions is returned when versions applied to the database have no migration, usually
+// because they were applied from a branch that was dropped. Their version rows can be removed with PruneOrphans.
+type ErrOrphanedVersions struct {
+	Versions []int64
+}
+
+func (err ErrOrphanedVersions) Error() string {
+	versions := make([]string, len(err.Versions))
+	for i, v := range err.Versions {
+		versions[i] = fmt.Sprint(v)
+	}
+	if len(versions) == 1 {
+		return fmt.Sprintf("no migration for version %s, which is applied to the database", versions[0])
+	}
+	return fmt.Sprintf("no migrations for versions %s, which are applied to the database", strings.Join(versions, ", "))
+}
@@ -1,5 +1,23 @@
 package goose
 
+import "time"
+
+// Eventer is an event sent by the commands to the provider's subscribers, see Provider.Subscribe,
+// and to the events channel given by WithEvents.
+//
+// The commands that apply migrations (Up, UpByOne, UpTo, Down, DownTo, Redo and Reset) all send
+// the same sequence of events, in either direction:
+//
+//  1. a VersionCountEvent with the number of migrations that will be applied, with TotalVersionsLeft set
+//     to 0 and Version to -1 if the command has nothing to do or fails before it counted them
+//  2. for each migration, a VersionApplyEvent with Applied set to false before it is applied,
+//     followed by either a VersionApplyEvent with Applied set to true, or a VersionFailedEvent
+//     carrying the error if the migration failed
+//  3. a CommandSummaryEvent once the command is done, successful or not
+//
+// Once the versioned migrations are up to date, Up sends a pair of RepeatableApplyEvent for each repeatable
+// migration it applies. SQL migrations also send a StatementStartEvent and StatementDoneEvent for each of
+// their statements. The commands a FanOut runs send each of their events wrapped in a TargetEvent.
 type Eventer interface {
 	event()
 	IsEqual(e Eventer) bool
@@ -18,3 +36,142 @@ func AreEventsEqual(e1, e2 Eventer) bool {
 	}
 	return e1.IsEqual(e2)
 }
+
+// VersionFailedEvent is sent instead of the applied VersionApplyEvent when a migration fails.
+type VersionFailedEvent struct {
+	*Event     `json:"-"`
+	From       int64     `json:"from"`
+	FromSource string    `json:"from_source"`
+	To         int64     `json:"to"`
+	ToSource   string    `json:"to_source"`
+	FailedAt   time.Time `json:"failed_at"`
+	Missing    bool      `json:"missing"`
+	Versioned  bool      `json:"versioned"`
+	Down       bool      `json:"down"`
+	// Err is the error the migration failed with, usually a MigrationError
+	Err error `json:"-"`
+}
+
+func (e VersionFailedEvent) IsEqual(o Eventer) bool {
+	oe, ok := o.(VersionFailedEvent)
+	if !ok {
+		poe, ok := o.(*VersionFailedEvent)
+		if !ok || poe == nil {
+			return false
+		}
+		oe = *poe
+	}
+	return e.From == oe.From &&
+		e.FromSource == oe.FromSource &&
+		e.To == oe.To &&
+		e.ToSource == oe.ToSource &&
+		e.Missing == oe.Missing &&
+		e.Versioned == oe.Versioned &&
+		e.Down == oe.Down &&
+		(e.Err == nil) == (oe.Err == nil)
+}
+
+var (
+	_ = Eventer((*VersionFailedEvent)(nil))
+	_ = Eventer(VersionFailedEvent{})
+)
+
+// CommandSummaryEvent is the last event sent by a command that applies migrations.
+type CommandSummaryEvent struct {
+	*Event `json:"-"`
+	// Command is the name of the command, as used by Run; e.g. up, down-to, or reset
+	Command string `json:"command"`
+	Down    bool   `json:"down"`
+	// Applied is the number of migrations that were successfully applied
+	Applied int `json:"applied"`
+	// Version is the version the last successfully applied migration left the database at, or -1 if none were
+	Version int64 `json:"version"`
+	// Err is the error the command failed with, nil if the command was successful
+	Err error `json:"-"`
+}
+
+func (e CommandSummaryEvent) IsEqual(o Eventer) bool {
+	oe, ok := o.(CommandSummaryEvent)
+	if !ok {
+		poe, ok := o.(*CommandSummaryEvent)
+		if !ok || poe == nil {
+			return false
+		}
+		oe = *poe
+	}
+	return e.Command == oe.Command &&
+		e.Down == oe.Down &&
+		e.Applied == oe.Applied &&
+		e.Version == oe.Version &&
+		(e.Err == nil) == (oe.Err == nil)
+}
+
+var (
+	_ = Eventer((*CommandSummaryEvent)(nil))
+	_ = Eventer(CommandSummaryEvent{})
+)
+
+// StatementStartEvent is emitted before a statement of a SQL migration is executed.
+type StatementStartEvent struct {
+	*Event         `json:"-"`
+	Version        int64 `json:"version"`
+	StatementIndex int   `json:"statement_index"`
+	// Statement is the statement being executed, with comments removed and truncated to 200 characters
+	Statement string    `json:"statement"`
+	StartAt   time.Time `json:"start_at"`
+}
+
+func (e StatementStartEvent) IsEqual(o Eventer) bool {
+	oe, ok := o.(StatementStartEvent)
+	if !ok {
+		poe, ok := o.(*StatementStartEvent)
+		if !ok || poe == nil {
+			return false
+		}
+		oe = *poe
+	}
+	return e.Version == oe.Version &&
+		e.StatementIndex == oe.StatementIndex &&
+		e.Statement == oe.Statement
+}
+
+var (
+	_ = Eventer((*StatementStartEvent)(nil))
+	_ = Eventer(StatementStartEvent{})
+)
+
+// StatementDoneEvent is emitted after a statement of a SQL migration has been executed, successfully or not.
+type StatementDoneEvent struct {
+	*Event         `json:"-"`
+	Version        int64 `json:"version"`
+	StatementIndex int   `json:"statement_index"`
+	// Statement is the statement that was executed, with comments removed and truncated to 200 characters
+	Statement string        `json:"statement"`
+	StartAt   time.Time     `json:"start_at"`
+	Duration  time.Duration `json:"duration"`
+	// RowsAffected is the number of rows affected by the statement, or -1 if the driver does not support it
+	RowsAffected int64 `json:"rows_affected"`
+	// Err is the error the statement failed with, nil if it succeeded
+	Err error `json:"-"`
+}
+
+func (e StatementDoneEvent) IsEqual(o Eventer) bool {
+	oe, ok := o.(StatementDoneEvent)
+	if !ok {
+		poe, ok := o.(*StatementDoneEvent)
+		if !ok || poe == nil {
+			return false
+		}
+		oe = *poe
+	}
+	return e.Version == oe.Version &&
+		e.StatementIndex == oe.StatementIndex &&
+		e.Statement == oe.Statement &&
+		e.RowsAffected == oe.RowsAffected &&
+		(e.Err == nil) == (oe.Err == nil)
+}
+
+var (
+	_ = Eventer((*StatementDoneEvent)(nil))
+	_ = Eventer(StatementDoneEvent{})
+)
@@ -0,0 +1,208 @@
+package goose
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// eventTypeKey and eventErrorKey are the keys added to the JSON encoding of every event
+const (
+	eventTypeKey  = "type"
+	eventErrorKey = "error"
+)
+
+var eventTypes = struct {
+	lck    sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: map[string]reflect.Type{},
+	byType: map[reflect.Type]string{},
+}
+
+func init() {
+	RegisterEventType("version_count", VersionCountEvent{})
+	RegisterEventType("version_apply", VersionApplyEvent{})
+	RegisterEventType("version_failed", VersionFailedEvent{})
+	RegisterEventType("command_summary", CommandSummaryEvent{})
+	RegisterEventType("status", StatusEvent{})
+	RegisterEventType("statement_start", StatementStartEvent{})
+	RegisterEventType("statement_done", StatementDoneEvent{})
+	RegisterEventType("repeatable_apply", RepeatableApplyEvent{})
+	RegisterEventType("target", TargetEvent{})
+}
+
+// RegisterEventType registers the name used as the "type" of the JSON encoding of the event type.
+// The event must be a struct value, not a pointer. An error field named Err is encoded as its message
+// under the "error" key.
+func RegisterEventType(name string, event Eventer) {
+	t := reflect.TypeOf(event)
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("goose: event type %v must be a struct", t))
+	}
+	eventTypes.lck.Lock()
+	defer eventTypes.lck.Unlock()
+	if _, ok := eventTypes.byName[name]; ok {
+		panic(fmt.Sprintf("goose: event type %q already registered", name))
+	}
+	eventTypes.byName[name] = t
+	eventTypes.byType[t] = name
+}
+
+// MarshalEvent returns the JSON encoding of the event, with the registered name of its type under the "type" key.
+func MarshalEvent(e Eventer) ([]byte, error) {
+	v := reflect.ValueOf(e)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, errors.New("can not marshal nil event")
+		}
+		v = v.Elem()
+	}
+	eventTypes.lck.RLock()
+	name, ok := eventTypes.byType[v.Type()]
+	eventTypes.lck.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("event type %v is not registered", v.Type())
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields[eventTypeKey], err = json.Marshal(name); err != nil {
+		return nil, err
+	}
+	if errField := eventErrField(v); errField.IsValid() && !errField.IsNil() {
+		if fields[eventErrorKey], err = json.Marshal(errField.Interface().(error).Error()); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalEvent decodes an event encoded by MarshalEvent, the returned event is a value of the registered type.
+// An error is decoded as an error with the same message.
+func UnmarshalEvent(data []byte) (Eventer, error) {
+	var head struct {
+		Type  string  `json:"type"`
+		Error *string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, err
+	}
+	eventTypes.lck.RLock()
+	t, ok := eventTypes.byName[head.Type]
+	eventTypes.lck.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", head.Type)
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", head.Type, err)
+	}
+	if errField := eventErrField(v.Elem()); errField.IsValid() && head.Error != nil {
+		errField.Set(reflect.ValueOf(errors.New(*head.Error)))
+	}
+	return v.Elem().Interface().(Eventer), nil
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// eventErrField returns the Err field of the event struct, if it has one
+func eventErrField(v reflect.Value) reflect.Value {
+	f := v.FieldByName("Err")
+	if !f.IsValid() || f.Type() != errorType {
+		return reflect.Value{}
+	}
+	return f
+}
+
+// JSONEventWriter writes events as newline-delimited JSON. Its Handle method can be
+// subscribed to a provider, see Provider.Subscribe.
+type JSONEventWriter struct {
+	lck    sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	err    error
+}
+
+// NewJSONEventWriter returns a JSONEventWriter writing to w
+func NewJSONEventWriter(w io.Writer) *JSONEventWriter {
+	return &JSONEventWriter{w: w}
+}
+
+// CreateJSONEventFile creates, or truncates, the named file and returns a JSONEventWriter writing to it.
+// The JSONEventWriter must be closed to close the file.
+func CreateJSONEventFile(name string) (*JSONEventWriter, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONEventWriter{w: f, closer: f}, nil
+}
+
+// Handle writes the event as a line of JSON. The first error encountered is kept, see Err,
+// and no more events are written after it.
+func (w *JSONEventWriter) Handle(e Eventer) {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	if w.err != nil {
+		return
+	}
+	data, err := MarshalEvent(e)
+	if err != nil {
+		w.err = err
+		return
+	}
+	_, w.err = w.w.Write(append(data, '\n'))
+}
+
+// Err returns the first error encountered writing the events
+func (w *JSONEventWriter) Err() error {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	return w.err
+}
+
+// Close closes the file opened by CreateJSONEventFile, and returns the first error encountered writing the events.
+func (w *JSONEventWriter) Close() error {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	if w.closer != nil {
+		if err := w.closer.Close(); err != nil && w.err == nil {
+			w.err = err
+		}
+		w.closer = nil
+	}
+	return w.err
+}
+
+// ReadJSONEvents decodes the newline-delimited JSON events written by a JSONEventWriter
+func ReadJSONEvents(r io.Reader) ([]Eventer, error) {
+	var events []Eventer
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, scanBufSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		e, err := UnmarshalEvent(scanner.Bytes())
+		if err != nil {
+			return events, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
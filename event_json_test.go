@@ -0,0 +1,98 @@
+package goose
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMarshalEvent(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []Eventer{
+		VersionCountEvent{Version: 1, VersionSource: "00001_a.sql", TotalVersionsLeft: 2},
+		&VersionApplyEvent{From: 1, FromSource: "00001_a.sql", To: 2, ToSource: "00002_b.sql", ApplyAT: at, Applied: true, Versioned: true},
+		VersionFailedEvent{From: 2, To: 1, FailedAt: at, Down: true, Err: errors.New("failed")},
+		CommandSummaryEvent{Command: "up", Applied: 2, Version: 2},
+		StatusEvent{Source: "00001_a.sql", Version: 1, Versioned: true, AppliedAt: at},
+		StatementStartEvent{Version: 1, StatementIndex: 0, Statement: "SELECT 1;", StartAt: at},
+		StatementDoneEvent{Version: 1, Statement: "SELECT 1;", Duration: time.Second, RowsAffected: -1, Err: errors.New("failed")},
+		TargetEvent{Target: "tenant_a", Wrapped: VersionFailedEvent{From: 2, To: 1, FailedAt: at, Down: true, Err: errors.New("failed")}},
+	}
+	for _, e := range events {
+		data, err := MarshalEvent(e)
+		if err != nil {
+			t.Fatalf("marshal %T: %v", e, err)
+		}
+		got, err := UnmarshalEvent(data)
+		if err != nil {
+			t.Fatalf("unmarshal %s: %v", data, err)
+		}
+		if !AreEventsEqual(e, got) {
+			t.Errorf("round trip, got %+v expected %+v", got, e)
+		}
+	}
+
+	data, err := MarshalEvent(events[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"type":"version_failed"`) || !strings.Contains(string(data), `"error":"failed"`) {
+		t.Errorf("encoding, got %s expected the type and error keys", data)
+	}
+
+	if _, err := UnmarshalEvent([]byte(`{"type":"unknown"}`)); err == nil {
+		t.Errorf("unknown type, expected an error")
+	}
+}
+
+func TestJSONEventWriter(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+
+	var buf bytes.Buffer
+	w := NewJSONEventWriter(&buf)
+	sub := p.Subscribe(w.Handle)
+	if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	p.Unsubscribe(sub)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadJSONEvents(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Eventer{
+		VersionCountEvent{Version: -1, TotalVersionsLeft: 1},
+		VersionApplyEvent{From: -1, To: 1, ToSource: "migrations/00001_a.sql", Versioned: true},
+		StatementStartEvent{Version: 1, Statement: "CREATE TABLE a (id INTEGER);"},
+		StatementDoneEvent{Version: 1, Statement: "CREATE TABLE a (id INTEGER);"},
+		VersionApplyEvent{From: -1, To: 1, ToSource: "migrations/00001_a.sql", Applied: true, Versioned: true},
+		CommandSummaryEvent{Command: "up", Applied: 1, Version: 1},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("number of events, got %v expected %v", len(got), len(expected))
+	}
+	// the rows affected by DDL is up to the driver
+	if done, ok := got[3].(StatementDoneEvent); ok {
+		ddl := expected[3].(StatementDoneEvent)
+		ddl.RowsAffected = done.RowsAffected
+		expected[3] = ddl
+	}
+	for i := range expected {
+		if !AreEventsEqual(expected[i], got[i]) {
+			t.Errorf("event %d, got %+v expected %+v", i, got[i], expected[i])
+		}
+	}
+}
@@ -19,7 +19,7 @@ var (
 	dir          = flag.String("dir", dirPath(), "directory which hold the migration directories for pardi, belle and tests")
 	dbConnection = flag.String("connection", "database.db", "database connection string")
 	test         = flag.String("test", "", "create test migrations for given test dir in testdata/migrations/")
-	forPardi     = flag.Bool("pardi", false, "to create files for pardi set this flag to true, does not effect fix")
+	forPardi     = flag.Bool("pardi", false, "to create files for pardi set this flag to true, only effects create")
 )
 
 // dirPath finds the path where our migrations live, we assume it's the directories above
@@ -40,6 +40,29 @@ func testProvider(testDir string) (*goose.Provider, string) {
 	)
 	return p, testPath
 }
+
+// providerGroup returns the group of the pardi and belle providers, belle's migrations run after pardi's,
+// and the providers of the given test dirs, which run after both
+func providerGroup(tests ...string) *goose.ProviderGroup {
+	group := goose.NewProviderGroup()
+	if err := group.Add("pardi", pardi.Provider); err != nil {
+		log.Fatalf("provider group: %v", err)
+	}
+	if err := group.Add("belle", belle.Provider, goose.GroupAfter("pardi")); err != nil {
+		log.Fatalf("provider group: %v", err)
+	}
+	for _, test := range tests {
+		if test == "" {
+			continue
+		}
+		p, path := testProvider(test)
+		if err := group.Add("test_"+test, p, goose.GroupDir(path), goose.GroupAfter("belle")); err != nil {
+			log.Fatalf("provider group: %v", err)
+		}
+	}
+	return group
+}
+
 func getAllTests() []string {
 	testPath := filepath.Join(*dir, "testdata", "migrations")
 	entries, err := os.ReadDir(testPath)
@@ -78,53 +101,17 @@ func main() {
 
 	switch command {
 	case "up":
-		err := p.Up(db, ".")
-		if err != nil {
-			log.Fatalf("%s error: %v", who, err)
-		}
-		if *test != "" {
-			p, path := testProvider(*test)
-			err = p.Up(db, path)
-			if err != nil {
-				log.Fatalf("test %s error: %v", *test, err)
-			}
+		if err := providerGroup(*test).Up(db); err != nil {
+			log.Fatalf("up error: %v", err)
 		}
 	case "fix":
-		// first fix pardi then belle, then all the test dirs
-		log.Printf("fixing pardi migration files:\n")
-		err := pardi.Provider.Fix(".")
-		if err != nil {
-			log.Fatalf("pardi error: %v", err)
-		}
-		log.Printf("fixing belle migration files:\n")
-		err = belle.Provider.Fix(".")
-		if err != nil {
-			log.Fatalf("belle error: %v", err)
-		}
-		for _, dir := range getAllTests() {
-			log.Printf("fixing test %v migration files:\n", dir)
-			p, path := testProvider(dir)
-			err = p.Fix(path)
-			if err != nil {
-				log.Fatalf("test %v error: %v", dir, err)
-			}
+		// fixes pardi then belle, then all the test dirs
+		if err := providerGroup(getAllTests()...).Fix(); err != nil {
+			log.Fatalf("fix error: %v", err)
 		}
-
 	case "status":
-		log.Printf("For %s migration:\n", who)
-		err = p.Status(db, ".")
-		if err != nil {
-			log.Printf("Failed to get status for %s: %s", who, err)
-		}
-		if *test != "" {
-			// output the status of the test dir
-			// assume test dir always exists to keep example simple
-			log.Printf("For test migration: %v\n", *test)
-			p, path := testProvider(*test)
-			err = p.Status(db, path)
-			if err != nil {
-				log.Printf("Failed to get status for test %s: %s", *test, err)
-			}
+		if err := providerGroup(*test).Status(db); err != nil {
+			log.Printf("Failed to get status: %s", err)
 		}
 	case "create":
 		tplType := "sql"
@@ -0,0 +1,332 @@
+package goose
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Target is a database a FanOut runs a command against.
+type Target struct {
+	// Name identifies the target in the report and the events
+	Name string
+	// DB is the database of the target. If it is nil the database is opened with Driver and DBString,
+	// and closed once the command is done.
+	DB       *sql.DB
+	Driver   string
+	DBString string
+	// Schema, if set, is the schema the command is run in; the search_path for postgres and redshift, or
+	// the database for mysql and tidb. It is only used when the database is opened with Driver and DBString.
+	Schema string
+}
+
+// TargetResult is the outcome of running the command against a target.
+type TargetResult struct {
+	Target string
+	// Err is the error the command failed with, nil if it was successful
+	Err error
+	// Skipped is set if the command was not run against the target, because the FanOut stopped
+	// at an earlier failure
+	Skipped  bool
+	Duration time.Duration
+}
+
+// FanOutReport is the result of running the command against each of the targets, in the order of the targets.
+type FanOutReport struct {
+	Results []TargetResult
+}
+
+// Failed returns the results of the targets the command failed for
+func (r FanOutReport) Failed() []TargetResult {
+	var failed []TargetResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err returns the errors of the targets the command failed for, each as an ErrTarget, or nil
+// if the command succeeded for all the targets that it was run against.
+func (r FanOutReport) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, ErrTarget{Target: result.Target, ErrUnwrap: ErrUnwrap{result.Err}})
+	}
+	return errors.Join(errs...)
+}
+
+// TargetEvent wraps each event sent by a command a FanOut runs, with the name of the target it is run against.
+type TargetEvent struct {
+	*Event `json:"-"`
+	Target string `json:"target"`
+	// Wrapped is the event sent by the command
+	Wrapped Eventer `json:"-"`
+}
+
+func (e TargetEvent) IsEqual(o Eventer) bool {
+	oe, ok := o.(TargetEvent)
+	if !ok {
+		poe, ok := o.(*TargetEvent)
+		if !ok || poe == nil {
+			return false
+		}
+		oe = *poe
+	}
+	return e.Target == oe.Target && AreEventsEqual(e.Wrapped, oe.Wrapped)
+}
+
+var (
+	_ = Eventer((*TargetEvent)(nil))
+	_ = Eventer(TargetEvent{})
+)
+
+// targetEventJSON is the JSON encoding of a TargetEvent, the wrapped event is encoded by MarshalEvent
+type targetEventJSON struct {
+	Target string          `json:"target"`
+	Event  json.RawMessage `json:"event"`
+}
+
+func (e TargetEvent) MarshalJSON() ([]byte, error) {
+	event := json.RawMessage("null")
+	if e.Wrapped != nil {
+		var err error
+		if event, err = MarshalEvent(e.Wrapped); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(targetEventJSON{Target: e.Target, Event: event})
+}
+
+func (e *TargetEvent) UnmarshalJSON(data []byte) error {
+	var raw targetEventJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	e.Target = raw.Target
+	e.Wrapped = nil
+	if len(raw.Event) == 0 || string(raw.Event) == "null" {
+		return nil
+	}
+	wrapped, err := UnmarshalEvent(raw.Event)
+	if err != nil {
+		return err
+	}
+	e.Wrapped = wrapped
+	return nil
+}
+
+// withTarget wraps the events sent by the command in a TargetEvent for the target
+func withTarget(name string) OptionsFunc {
+	return func(o *options) { o.target = name }
+}
+
+// FanOutOption configures a FanOut
+type FanOutOption func(f *FanOut)
+
+// FanOutConcurrency sets the number of targets the command is run against at the same time, by default one.
+func FanOutConcurrency(n int) FanOutOption {
+	return func(f *FanOut) {
+		if n < 1 {
+			n = 1
+		}
+		f.concurrency = n
+	}
+}
+
+// FanOutContinueOnError makes the FanOut run the command against all the targets, instead of stopping
+// at the first target the command fails for.
+func FanOutContinueOnError(b bool) FanOutOption {
+	return func(f *FanOut) {
+		f.continueOnError = b
+	}
+}
+
+// FanOut runs the same command against many databases, or schemas, with the migrations of a Provider.
+// All the targets must use the dialect of the provider.
+type FanOut struct {
+	provider        *Provider
+	targets         []Target
+	concurrency     int
+	continueOnError bool
+}
+
+// NewFanOut returns a FanOut running the commands of the provider against the targets.
+func NewFanOut(p *Provider, targets []Target, opts ...FanOutOption) *FanOut {
+	if p == nil {
+		p = defaultProvider
+	}
+	f := &FanOut{
+		provider:    p,
+		targets:     targets,
+		concurrency: 1,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Run runs the command, as Provider.RunWithOptions does, against each of the targets. The events of each
+// command are sent wrapped in a TargetEvent. Unless FanOutContinueOnError is set no more targets are started
+// once the command fails for one, and the targets not started are reported as skipped. If the driver of a
+// target is of another dialect than the provider's the command is not run against any target, those targets
+// fail with an ErrTargetDialect and the others are skipped.
+func (f *FanOut) Run(command string, dir string, args []string, opts ...OptionsFunc) FanOutReport {
+	report := FanOutReport{Results: make([]TargetResult, len(f.targets))}
+	if !f.checkDialects(report) {
+		return report
+	}
+	var (
+		wg      sync.WaitGroup
+		stopped int32
+		next    = make(chan int)
+	)
+	for w := 0; w < f.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				result := &report.Results[i]
+				result.Target = f.targets[i].Name
+				if atomic.LoadInt32(&stopped) != 0 {
+					result.Skipped = true
+					continue
+				}
+				start := time.Now()
+				result.Err = f.runTarget(f.targets[i], command, dir, args, opts)
+				result.Duration = time.Since(start)
+				if result.Err != nil && !f.continueOnError {
+					atomic.StoreInt32(&stopped, 1)
+				}
+			}
+		}()
+	}
+	for i := range f.targets {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return report
+}
+
+// checkDialects checks the driver of each target is of the provider's dialect, the results of the report are
+// set if one is not. The drivers that are not the name of a dialect, and the targets of a generic dialect,
+// which is used with any driver, are not checked.
+func (f *FanOut) checkDialects(report FanOutReport) bool {
+	ok := true
+	if _, generic := f.provider.dialect.(*GenericDialect); generic {
+		return ok
+	}
+	for i, target := range f.targets {
+		report.Results[i].Target = target.Name
+		if target.Driver == "" {
+			continue
+		}
+		d, err := SelectDialect(f.provider.tableName, target.Driver)
+		if err == nil && reflect.TypeOf(d) != reflect.TypeOf(f.provider.dialect) {
+			report.Results[i].Err = ErrTargetDialect{Driver: target.Driver}
+			ok = false
+		}
+	}
+	if !ok {
+		for i := range report.Results {
+			report.Results[i].Skipped = report.Results[i].Err == nil
+		}
+	}
+	return ok
+}
+
+// runTarget runs the command against the target, opening its database if needed
+func (f *FanOut) runTarget(target Target, command string, dir string, args []string, opts []OptionsFunc) error {
+	db := target.DB
+	if db == nil {
+		dbstring, err := schemaDBString(target.Driver, target.DBString, target.Schema)
+		if err != nil {
+			return err
+		}
+		if db, err = openDB(target.Driver, dbstring); err != nil {
+			return err
+		}
+		defer db.Close()
+	}
+	opts = append(opts[:len(opts):len(opts)], withTarget(target.Name))
+	return f.provider.RunWithOptions(command, db, dir, args, opts...)
+}
+
+var matchMySQLDatabase = regexp.MustCompile(`^([^/]*/)([^?]*)(.*)$`)
+
+// schemaDBString returns the dbstring to connect to the schema with
+func schemaDBString(driver, dbstring, schema string) (string, error) {
+	if schema == "" {
+		return dbstring, nil
+	}
+	switch driver {
+	case DialectPostgres, "pgx", DialectRedShit:
+		if strings.HasPrefix(dbstring, "postgres://") || strings.HasPrefix(dbstring, "postgresql://") {
+			u, err := url.Parse(dbstring)
+			if err != nil {
+				return "", err
+			}
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String(), nil
+		}
+		return fmt.Sprintf("%s search_path='%s'", dbstring, strings.ReplaceAll(schema, "'", `\'`)), nil
+	case DialectMySQL, DialectTiDB:
+		m := matchMySQLDatabase.FindStringSubmatch(dbstring)
+		if m == nil {
+			return "", fmt.Errorf("%q: can not set the database of the dbstring", dbstring)
+		}
+		return m[1] + schema + m[3], nil
+	default:
+		return "", fmt.Errorf("%s: schema targets are not supported", driver)
+	}
+}
+
+// ReadTargets reads the targets from r, one per line. A line is either the name of a schema, which is used as
+// the name of the target, with the given driver and dbstring, or the name of the target, its driver and its
+// dbstring separated by spaces. Empty lines and lines starting with # are ignored.
+func ReadTargets(r io.Reader, driver, dbstring string) ([]Target, error) {
+	var targets []Target
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		switch len(fields) {
+		case 1:
+			if driver == "" {
+				return nil, fmt.Errorf("line %d: schema target %q needs a driver and dbstring", line, fields[0])
+			}
+			targets = append(targets, Target{Name: fields[0], Driver: driver, DBString: dbstring, Schema: fields[0]})
+		case 2:
+			return nil, fmt.Errorf("line %d: expected a schema, or a name, driver and dbstring", line)
+		default:
+			name, rest := fields[0], strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+			targetDriver := fields[1]
+			targets = append(targets, Target{
+				Name:     name,
+				Driver:   targetDriver,
+				DBString: strings.TrimSpace(strings.TrimPrefix(rest, targetDriver)),
+			})
+		}
+	}
+	return targets, scanner.Err()
+}
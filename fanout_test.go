@@ -0,0 +1,166 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFanOut(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/00002_b.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+	}
+	// targets returns the targets, the database of the target named broken already has table a
+	targets := func(t *testing.T, names ...string) []Target {
+		dir := t.TempDir()
+		list := make([]Target, 0, len(names))
+		for _, name := range names {
+			target := Target{Name: name, Driver: "sqlite", DBString: filepath.Join(dir, name+".db")}
+			if name == "broken" {
+				db, err := sql.Open("sqlite", target.DBString)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = db.Exec("CREATE TABLE a (id INTEGER)")
+				db.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			list = append(list, target)
+		}
+		return list
+	}
+
+	t.Run("continue on error", func(t *testing.T) {
+		t.Parallel()
+		p := NewProvider(Filesystem(fsys), Dialect(DialectSQLite3))
+		var (
+			lck     sync.Mutex
+			applied = map[string]int{}
+		)
+		sub := p.Subscribe(func(e Eventer) {
+			te, ok := e.(TargetEvent)
+			if !ok {
+				t.Errorf("event, got %T expected a TargetEvent", e)
+				return
+			}
+			if apply, ok := te.Wrapped.(VersionApplyEvent); ok && apply.Applied {
+				lck.Lock()
+				applied[te.Target]++
+				lck.Unlock()
+			}
+		})
+		defer p.Unsubscribe(sub)
+
+		report := NewFanOut(p, targets(t, "one", "broken", "two"), FanOutConcurrency(2), FanOutContinueOnError(true)).
+			Run("up", "migrations", nil, WithNoOutput())
+		if len(report.Results) != 3 {
+			t.Fatalf("results, got %v expected 3", report.Results)
+		}
+		for i, name := range []string{"one", "broken", "two"} {
+			result := report.Results[i]
+			if result.Target != name || result.Skipped || (result.Err != nil) != (name == "broken") {
+				t.Errorf("result %d, got %+v expected %s to fail: %v", i, result, name, name == "broken")
+			}
+		}
+		var terr ErrTarget
+		if err := report.Err(); !errors.As(err, &terr) || terr.Target != "broken" {
+			t.Errorf("error, got %v expected an ErrTarget for broken", err)
+		}
+		if applied["one"] != 2 || applied["two"] != 2 || applied["broken"] != 0 {
+			t.Errorf("applied, got %v expected 2 for one and two", applied)
+		}
+	})
+	t.Run("stop on error", func(t *testing.T) {
+		t.Parallel()
+		p := NewProvider(Filesystem(fsys), Dialect(DialectSQLite3))
+		report := NewFanOut(p, targets(t, "broken", "one", "two")).Run("up", "migrations", nil, WithNoOutput())
+		if report.Results[0].Err == nil {
+			t.Errorf("result broken, got %+v expected an error", report.Results[0])
+		}
+		for _, result := range report.Results[1:] {
+			if !result.Skipped || result.Err != nil {
+				t.Errorf("result %s, got %+v expected it to be skipped", result.Target, result)
+			}
+		}
+		if failed := report.Failed(); len(failed) != 1 {
+			t.Errorf("failed, got %v expected 1", failed)
+		}
+	})
+	t.Run("other dialect", func(t *testing.T) {
+		t.Parallel()
+		p := NewProvider(Filesystem(fsys), Dialect(DialectSQLite3))
+		list := targets(t, "one", "two")
+		list[1].Driver = DialectPostgres
+		report := NewFanOut(p, list, FanOutContinueOnError(true)).Run("up", "migrations", nil, WithNoOutput())
+		var derr ErrTargetDialect
+		if !errors.As(report.Results[1].Err, &derr) || derr.Driver != DialectPostgres {
+			t.Errorf("result two, got %+v expected an ErrTargetDialect", report.Results[1])
+		}
+		if !report.Results[0].Skipped || report.Results[0].Err != nil {
+			t.Errorf("result one, got %+v expected it to be skipped", report.Results[0])
+		}
+		if _, err := os.Stat(list[0].DBString); !os.IsNotExist(err) {
+			t.Errorf("database of one, got %v expected it not to be created", err)
+		}
+	})
+}
+
+func TestSchemaDBString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		driver, dbstring, schema string
+		expected                 string
+	}{
+		{DialectPostgres, "user=postgres dbname=app", "tenant_a", "user=postgres dbname=app search_path='tenant_a'"},
+		{DialectPostgres, "postgres://u:p@localhost:5432/app?sslmode=disable", "tenant_a", "postgres://u:p@localhost:5432/app?search_path=tenant_a&sslmode=disable"},
+		{DialectMySQL, "user:password@tcp(localhost:3306)/app?parseTime=true", "tenant_a", "user:password@tcp(localhost:3306)/tenant_a?parseTime=true"},
+		{DialectSQLite3, "foo.db", "", "foo.db"},
+	}
+	for _, tc := range tests {
+		got, err := schemaDBString(tc.driver, tc.dbstring, tc.schema)
+		if err != nil || got != tc.expected {
+			t.Errorf("schemaDBString(%q, %q, %q), got %q, %v expected %q", tc.driver, tc.dbstring, tc.schema, got, err, tc.expected)
+		}
+	}
+	if _, err := schemaDBString(DialectSQLite3, "foo.db", "tenant_a"); err == nil {
+		t.Errorf("schemaDBString sqlite3 schema, got nil expected an error")
+	}
+}
+
+func TestReadTargets(t *testing.T) {
+	t.Parallel()
+	input := `# tenants
+tenant_a
+
+other postgres user=postgres dbname=other
+`
+	got, err := ReadTargets(strings.NewReader(input), DialectPostgres, "dbname=app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Target{
+		{Name: "tenant_a", Driver: DialectPostgres, DBString: "dbname=app", Schema: "tenant_a"},
+		{Name: "other", Driver: DialectPostgres, DBString: "user=postgres dbname=other"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("targets, got %+v expected %+v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("target %d, got %+v expected %+v", i, got[i], expected[i])
+		}
+	}
+	if _, err := ReadTargets(strings.NewReader("tenant_a\n"), "", ""); err == nil {
+		t.Errorf("schema without a driver, got nil expected an error")
+	}
+}
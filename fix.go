@@ -1,33 +1,100 @@
 package goose
 
 import (
+	"errors"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 const seqVersionTemplate = "%05v"
 
-func Fix(dir string) error { return defaultProvider.Fix(dir) }
+// FixRename is a single rename that Fix will apply to a timestamp based migration.
+type FixRename struct {
+	OldPath    string
+	NewPath    string
+	OldVersion int64
+	NewVersion int64
+}
+
+func (r FixRename) String() string {
+	return fmt.Sprintf("%s => %s", filepath.Base(r.OldPath), filepath.Base(r.NewPath))
+}
+
+// Fix will rename timestamp based migrations into sequential migrations
+func Fix(dir string, opts ...OptionsFunc) error { return defaultProvider.Fix(dir, opts...) }
+
+// Fix will rename timestamp based migrations into sequential migrations. The full set of renames
+// is planned and validated before any file is touched; if a rename fails, the renames already done
+// are rolled back. Use WithDryRun to only print the renames.
+func (p *Provider) Fix(dir string, opts ...OptionsFunc) error {
+	option := p.applyOptions(opts)
+	plan, err := p.FixPlan(dir)
+	if err != nil {
+		return err
+	}
+
+	if option.dryRun {
+		for _, r := range plan {
+			if !option.noOutput {
+				p.log.Printf("WOULD RENAME %s", r)
+			}
+		}
+		return nil
+	}
+
+	for i, r := range plan {
+		if err := os.Rename(r.OldPath, r.NewPath); err != nil {
+			return rollbackFix(plan[:i], fmt.Errorf("failed to rename %s: %w", r, err))
+		}
+		if !option.noOutput {
+			p.log.Printf("RENAMED %s", r)
+		}
+	}
+
+	return nil
+}
+
+// rollbackFix undoes the given renames, in reverse order. The returned error
+// wraps err and any error encountered while rolling back.
+func rollbackFix(done []FixRename, err error) error {
+	errs := []error{err}
+	for i := len(done) - 1; i >= 0; i-- {
+		if rerr := os.Rename(done[i].NewPath, done[i].OldPath); rerr != nil {
+			errs = append(errs, fmt.Errorf("failed to roll back rename %s: %w", done[i], rerr))
+		}
+	}
+	return errors.Join(errs...)
+}
 
-func (p *Provider) Fix(dir string) error {
+// FixPlan returns the renames Fix would make to the migrations in dir, without modifying anything.
+// An ErrFixConflict is returned if any of the renames would not be safe to apply.
+func FixPlan(dir string) ([]FixRename, error) { return defaultProvider.FixPlan(dir) }
+
+// FixPlan returns the renames Fix would make to the migrations in dir, without modifying anything.
+// An ErrFixConflict is returned if any of the renames would not be safe to apply.
+func (p *Provider) FixPlan(dir string) ([]FixRename, error) {
 	dir = p.BaseDir(dir)
 	// always use osFS here because it's modifying operation
 	migrations, err := p.collectMigrationsFS(osFS{}, dir, minVersion, maxVersion)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// split into timestamped and versioned migrations
 	tsMigrations, err := migrations.timestamped()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	vMigrations, err := migrations.versioned()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Initial version.
 	version := int64(1)
@@ -39,23 +106,76 @@ func (p *Provider) Fix(dir string) error {
 	if seqVerTemplate == "" {
 		seqVerTemplate = seqVersionTemplate
 	}
-	// fix filenames by replacing timestamps with sequential versions
-	for _, tsm := range tsMigrations {
-		oldPath := tsm.Source
-		newPath := strings.Replace(
-			oldPath,
-			fmt.Sprintf("%d", tsm.Version),
-			fmt.Sprintf(seqVerTemplate, version),
-			1,
-		)
 
-		if err := os.Rename(oldPath, newPath); err != nil {
-			return err
+	plan := make([]FixRename, 0, len(tsMigrations))
+	targets := make(map[string]FixRename, len(tsMigrations))
+	for _, tsm := range tsMigrations {
+		r, err := fixRename(tsm, version, seqVerTemplate)
+		if err != nil {
+			return nil, err
 		}
-
-		p.log.Printf("RENAMED %s => %s", filepath.Base(oldPath), filepath.Base(newPath))
+		if other, ok := targets[r.NewPath]; ok {
+			return nil, ErrFixConflict{Rename: r, Reason: fmt.Sprintf("target is also the target of %s", other)}
+		}
+		if _, err := os.Lstat(r.NewPath); err == nil {
+			return nil, ErrFixConflict{Rename: r, Reason: "target file already exists"}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, ErrFixConflict{Rename: r, Reason: err.Error()}
+		}
+		if getExtension(r.OldPath) == ".go" {
+			if err := checkGoFixRename(r); err != nil {
+				return nil, err
+			}
+		}
+		targets[r.NewPath] = r
+		plan = append(plan, r)
 		version++
 	}
+	return plan, nil
+}
 
+// fixRename builds the rename for the migration, only the version prefix of the base name is replaced.
+func fixRename(m *Migration, version int64, seqVerTemplate string) (FixRename, error) {
+	r := FixRename{
+		OldPath:    m.Source,
+		OldVersion: m.Version,
+		NewVersion: version,
+	}
+	base := filepath.Base(m.Source)
+	idx := strings.Index(base, "_")
+	if idx < 0 || base[:idx] != strconv.FormatInt(m.Version, 10) {
+		return r, ErrFixConflict{Rename: r, Reason: "file name does not start with its version"}
+	}
+	newVersion := fmt.Sprintf(seqVerTemplate, version)
+	if v, err := strconv.ParseInt(newVersion, 10, 64); err != nil || v != version {
+		return r, ErrFixConflict{Rename: r, Reason: fmt.Sprintf("sequential version template %q does not produce version %d", seqVerTemplate, version)}
+	}
+	r.NewPath = filepath.Join(filepath.Dir(m.Source), newVersion+base[idx:])
+	return r, nil
+}
+
+// checkGoFixRename makes sure the go migration will still compile, and still register
+// the same migration after the rename.
+func checkGoFixRename(r FixRename) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, r.OldPath, nil, 0)
+	if err != nil {
+		return ErrFixConflict{Rename: r, Reason: fmt.Sprintf("failed to parse go migration: %v", err)}
+	}
+	oldBase := filepath.Base(r.OldPath)
+	var reason string
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || reason != "" || lit.Kind != token.STRING {
+			return reason == ""
+		}
+		if strings.Contains(lit.Value, oldBase) {
+			reason = fmt.Sprintf("%s references its own file name at line %d", oldBase, fset.Position(lit.Pos()).Line)
+		}
+		return false
+	})
+	if reason != "" {
+		return ErrFixConflict{Rename: r, Reason: reason}
+	}
 	return nil
 }
@@ -1,10 +1,12 @@
 package goose
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -78,3 +80,97 @@ func TestFix(t *testing.T) {
 		}
 	}
 }
+
+func TestFixPlan(t *testing.T) {
+	t.Parallel()
+
+	writeFiles := func(t *testing.T, dir string, files map[string]string) {
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	const sqlFile = "-- +goose Up\nSELECT 1;\n"
+
+	t.Run("dry run", func(t *testing.T) {
+		// digits from the timestamp in the directory name should not be touched
+		dir := filepath.Join(t.TempDir(), "20220101000000")
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, dir, map[string]string{
+			"00001_a.sql":          sqlFile,
+			"20220101000000_b.sql": sqlFile,
+			"20220102000000_c.sql": sqlFile,
+		})
+		p := NewProvider()
+		if err := p.Fix(dir, WithDryRun(), WithNoOutput()); err != nil {
+			t.Fatalf("dry run, got %v expected nil", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "20220101000000_b.sql")); err != nil {
+			t.Fatalf("dry run renamed files: %v", err)
+		}
+		plan, err := p.FixPlan(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{
+			filepath.Join(dir, "00002_b.sql"),
+			filepath.Join(dir, "00003_c.sql"),
+		}
+		if len(plan) != len(expected) {
+			t.Fatalf("plan length, got %v expected %v", len(plan), len(expected))
+		}
+		for i := range expected {
+			if plan[i].NewPath != expected[i] {
+				t.Errorf("plan[%d] new path, got %v expected %v", i, plan[i].NewPath, expected[i])
+			}
+		}
+		if err := p.Fix(dir, WithNoOutput()); err != nil {
+			t.Fatalf("fix, got %v expected nil", err)
+		}
+		for _, name := range expected {
+			if _, err := os.Stat(name); err != nil {
+				t.Errorf("expected %v to exist: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("bad version template", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"00001_a.sql":          sqlFile,
+			"20220101000000_b.sql": sqlFile,
+		})
+		p := NewProvider(SequentialVersion("v%d"))
+		err := p.Fix(dir, WithNoOutput())
+		var conflict ErrFixConflict
+		if !errors.As(err, &conflict) {
+			t.Fatalf("error, got %v expected ErrFixConflict", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "20220101000000_b.sql")); err != nil {
+			t.Errorf("expected no renames to be done: %v", err)
+		}
+	})
+
+	t.Run("go migration references file name", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"20220101000000_a.go": `package migrations
+
+import "github.com/gdey/goose/v3"
+
+func init() {
+	goose.AddNamedMigration("20220101000000_a.go", nil, nil)
+}
+`,
+		})
+		p := NewProvider()
+		_, err := p.FixPlan(dir)
+		var conflict ErrFixConflict
+		if !errors.As(err, &conflict) {
+			t.Fatalf("error, got %v expected ErrFixConflict", err)
+		}
+	})
+}
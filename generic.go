@@ -0,0 +1,269 @@
+package goose
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+// PlaceholderStyle is how the parameters of a statement are written for the database's driver
+type PlaceholderStyle string
+
+const (
+	// PlaceholderDollar numbers the parameters as $1, $2, ...
+	PlaceholderDollar PlaceholderStyle = "$"
+	// PlaceholderQuestion writes each parameter as ?
+	PlaceholderQuestion PlaceholderStyle = "?"
+	// PlaceholderAtP numbers the parameters as @p1, @p2, ...
+	PlaceholderAtP PlaceholderStyle = "@p"
+	// PlaceholderColon numbers the parameters as :1, :2, ...
+	PlaceholderColon PlaceholderStyle = ":"
+)
+
+// placeholder returns the placeholder of the nth parameter, starting at 1
+func (ps PlaceholderStyle) placeholder(n int) string {
+	if ps == PlaceholderQuestion {
+		return "?"
+	}
+	return fmt.Sprintf("%s%d", ps, n)
+}
+
+const (
+	// DefaultGenericVersionTableDDL is the version table DDL of a GenericConfig that does not set one
+	DefaultGenericVersionTableDDL = `CREATE TABLE {{.Table}} (
+    id {{.AutoIncrement}},
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tags VARCHAR(255),
+    tstamp {{.Timestamp}}
+)`
+	// DefaultGenericAutoIncrement is the id column type of a GenericConfig that does not set one
+	DefaultGenericAutoIncrement = "INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY"
+	// DefaultGenericTimestamp is the tstamp column type of a GenericConfig that does not set one
+	DefaultGenericTimestamp = "TIMESTAMP DEFAULT CURRENT_TIMESTAMP"
+)
+
+// GenericConfig configures a GenericDialect, its JSON encoding is the goose command's -generic-config file.
+type GenericConfig struct {
+	// Placeholder is the placeholder style of the driver, by default PlaceholderQuestion
+	Placeholder PlaceholderStyle `json:"placeholder,omitempty"`
+	// Quote are the characters identifiers are quoted with, the same character for both sides, e.g. ",
+	// or the opening and closing characters, e.g. []. By default identifiers are double quoted.
+	Quote string `json:"quote,omitempty"`
+	// VersionTableDDL is the text/template of the statement creating the version table, executed with
+	// the quoted Table, AutoIncrement and Timestamp. It must have the version_id, is_applied and tstamp
+	// columns, and the OrderBy column; a nullable tags column is added when the tags are first recorded if
+	// it does not have one. By default it is DefaultGenericVersionTableDDL.
+	VersionTableDDL string `json:"version_table_ddl,omitempty"`
+	// AutoIncrement is the type of the id column, by default DefaultGenericAutoIncrement
+	AutoIncrement string `json:"auto_increment,omitempty"`
+	// Timestamp is the type of the tstamp columns, by default DefaultGenericTimestamp
+	Timestamp string `json:"timestamp,omitempty"`
+	// OrderBy is the column the rows of the version table are ordered by, the most recent being the
+	// largest, by default id.
+	OrderBy string `json:"order_by,omitempty"`
+	// AlterTableDelete deletes rows with ALTER TABLE ... DELETE WHERE, as ClickHouse does, instead of DELETE FROM
+	AlterTableDelete bool `json:"alter_table_delete,omitempty"`
+	// Capabilities are the capabilities of the database, by default DefaultCapabilities
+	Capabilities *DialectCapabilities `json:"capabilities,omitempty"`
+}
+
+// ReadGenericConfig reads the JSON encoded GenericConfig from r
+func ReadGenericConfig(r io.Reader) (GenericConfig, error) {
+	var config GenericConfig
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return GenericConfig{}, fmt.Errorf("failed to read generic dialect config: %w", err)
+	}
+	return config, nil
+}
+
+// GenericDialect is a dialect configured by a GenericConfig, for the databases goose has no dialect for.
+type GenericDialect struct {
+	BaseDialect
+	config          GenericConfig
+	open, close     string
+	versionTableDDL *template.Template
+}
+
+// NewGenericDialect returns a GenericDialect for the config, the defaults are used for the fields
+// that are not set.
+func NewGenericDialect(config GenericConfig) (*GenericDialect, error) {
+	if config.Placeholder == "" {
+		config.Placeholder = PlaceholderQuestion
+	}
+	switch config.Placeholder {
+	case PlaceholderDollar, PlaceholderQuestion, PlaceholderAtP, PlaceholderColon:
+	default:
+		return nil, fmt.Errorf("generic dialect: unknown placeholder style %q", config.Placeholder)
+	}
+	if config.VersionTableDDL == "" {
+		config.VersionTableDDL = DefaultGenericVersionTableDDL
+	}
+	if config.AutoIncrement == "" {
+		config.AutoIncrement = DefaultGenericAutoIncrement
+	}
+	if config.Timestamp == "" {
+		config.Timestamp = DefaultGenericTimestamp
+	}
+	if config.OrderBy == "" {
+		config.OrderBy = "id"
+	}
+	d := &GenericDialect{config: config}
+	switch len(config.Quote) {
+	case 0:
+		d.open, d.close = `"`, `"`
+	case 1:
+		d.open, d.close = config.Quote, config.Quote
+	case 2:
+		d.open, d.close = config.Quote[:1], config.Quote[1:]
+	default:
+		return nil, fmt.Errorf("generic dialect: quote %q must be one or two characters", config.Quote)
+	}
+	ddl, err := template.New("version_table_ddl").Option("missingkey=error").Parse(config.VersionTableDDL)
+	if err != nil {
+		return nil, fmt.Errorf("generic dialect: version table DDL: %w", err)
+	}
+	d.versionTableDDL = ddl
+	if _, err := d.executeDDL(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GenericDialectFactory returns a DialectFactory of the GenericDialect for the config, to register it
+// under the name of a driver with RegisterDialect. It returns an error if the config is not valid.
+func GenericDialectFactory(config GenericConfig) (DialectFactory, error) {
+	if _, err := NewGenericDialect(config); err != nil {
+		return nil, err
+	}
+	return func(tableName string) SQLDialect {
+		// the config was checked, so it can not fail
+		d, _ := NewGenericDialect(config)
+		d.SetTableName(tableName)
+		return d
+	}, nil
+}
+
+// Config returns the config of the dialect, with the defaults filled in
+func (d *GenericDialect) Config() GenericConfig { return d.config }
+
+// table returns the quoted name of the version table, with the suffix, in its schema
+func (d *GenericDialect) table(suffix string) string { return d.QuotedTable(d.open, d.close, suffix) }
+
+// ph returns the placeholder of the nth parameter
+func (d *GenericDialect) ph(n int) string { return d.config.Placeholder.placeholder(n) }
+
+// executeDDL executes the version table DDL template
+func (d *GenericDialect) executeDDL() (string, error) {
+	var buff bytes.Buffer
+	err := d.versionTableDDL.Execute(&buff, struct {
+		Table, AutoIncrement, Timestamp string
+	}{d.table(""), d.config.AutoIncrement, d.config.Timestamp})
+	if err != nil {
+		return "", fmt.Errorf("generic dialect: version table DDL: %w", err)
+	}
+	return buff.String(), nil
+}
+
+// delete returns the statement deleting the rows of the table where column is the first parameter
+func (d *GenericDialect) delete(table, column string) string {
+	if d.config.AlterTableDelete {
+		return fmt.Sprintf("ALTER TABLE %s DELETE WHERE %s = %s", table, column, d.ph(1))
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE %s = %s", table, column, d.ph(1))
+}
+
+func (d *GenericDialect) CreateSchemaSQL() string {
+	schema := d.QuotedSchema(d.open, d.close)
+	if schema == "" {
+		return ""
+	}
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schema)
+}
+
+func (d *GenericDialect) CreateVersionTableSQL() string {
+	// the template was executed when the dialect was created
+	ddl, _ := d.executeDDL()
+	return ddl
+}
+
+func (d *GenericDialect) InsertVersionSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES (%s, %s)", d.table(""), d.ph(1), d.ph(2))
+}
+
+func (d *GenericDialect) DBVersionQuery(db *sql.DB) (*sql.Rows, error) {
+	return db.Query(fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY %s DESC", d.table(""), d.config.OrderBy))
+}
+
+func (d *GenericDialect) MigrationSQL() string {
+	// only the first row is read, so the statement does not need the dialect's LIMIT syntax
+	return fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id = %s ORDER BY tstamp DESC", d.table(""), d.ph(1))
+}
+
+func (d *GenericDialect) DeleteVersionSQL() string {
+	return d.delete(d.table(""), "version_id")
+}
+
+func (d *GenericDialect) CreateRepeatableTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+    name VARCHAR(255) NOT NULL PRIMARY KEY,
+    checksum VARCHAR(64) NOT NULL,
+    tstamp %s
+)`, d.table(RepeatableTableSuffix), d.config.Timestamp)
+}
+
+func (d *GenericDialect) InsertRepeatableSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (name, checksum) VALUES (%s, %s)", d.table(RepeatableTableSuffix), d.ph(1), d.ph(2))
+}
+
+func (d *GenericDialect) DeleteRepeatableSQL() string {
+	return d.delete(d.table(RepeatableTableSuffix), "name")
+}
+
+func (d *GenericDialect) RepeatableQuerySQL() string {
+	return fmt.Sprintf("SELECT name, checksum, tstamp FROM %s", d.table(RepeatableTableSuffix))
+}
+
+func (d *GenericDialect) AddTagsColumnSQL() string {
+	return fmt.Sprintf("ALTER TABLE %s ADD tags VARCHAR(255)", d.table(""))
+}
+
+func (d *GenericDialect) InsertVersionTagsSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tags) VALUES (%s, %s, %s)", d.table(""), d.ph(1), d.ph(2), d.ph(3))
+}
+
+func (d *GenericDialect) TagsQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, tags FROM %s WHERE tags IS NOT NULL ORDER BY %s", d.table(""), d.config.OrderBy)
+}
+
+var _ SQLDialect = (*GenericDialect)(nil)
+
+// OpenDBWithDialect creates a connection to a database with the database/sql driver, and uses the
+// dialect for it, e.g. a GenericDialect.
+func OpenDBWithDialect(driver string, dbstring string, dialect SQLDialect) (*sql.DB, error) {
+	return defaultProvider.OpenDBWithDialect(driver, dbstring, dialect)
+}
+
+// OpenDBWithDialect creates a connection to a database with the database/sql driver, and uses the
+// dialect for it, e.g. a GenericDialect.
+func (p *Provider) OpenDBWithDialect(driver string, dbstring string, dialect SQLDialect) (*sql.DB, error) {
+	if err := p.SetDialectObject(dialect); err != nil {
+		return nil, err
+	}
+	return openDB(driver, dbstring)
+}
+
+// isSQLDriver returns if a database/sql driver is registered under the name
+func isSQLDriver(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
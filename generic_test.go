@@ -0,0 +1,92 @@
+package goose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenericDialect(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		config         GenericConfig
+		insert, delete string
+	}{
+		{
+			config: GenericConfig{},
+			insert: `INSERT INTO "goose_db_version" (version_id, is_applied) VALUES (?, ?)`,
+			delete: `DELETE FROM "goose_db_version" WHERE version_id = ?`,
+		},
+		{
+			config: GenericConfig{Placeholder: PlaceholderDollar, Quote: "`"},
+			insert: "INSERT INTO `goose_db_version` (version_id, is_applied) VALUES ($1, $2)",
+			delete: "DELETE FROM `goose_db_version` WHERE version_id = $1",
+		},
+		{
+			config: GenericConfig{Placeholder: PlaceholderAtP, Quote: "[]"},
+			insert: "INSERT INTO [goose_db_version] (version_id, is_applied) VALUES (@p1, @p2)",
+			delete: "DELETE FROM [goose_db_version] WHERE version_id = @p1",
+		},
+		{
+			config: GenericConfig{Placeholder: PlaceholderColon, AlterTableDelete: true},
+			insert: `INSERT INTO "goose_db_version" (version_id, is_applied) VALUES (:1, :2)`,
+			delete: `ALTER TABLE "goose_db_version" DELETE WHERE version_id = :1`,
+		},
+	}
+	for _, tc := range tests {
+		d, err := NewGenericDialect(tc.config)
+		if err != nil {
+			t.Errorf("%+v: %v", tc.config, err)
+			continue
+		}
+		d.SetTableName("goose_db_version")
+		d.SetQuoteIdentifiers(true)
+		if got := d.InsertVersionSQL(); got != tc.insert {
+			t.Errorf("%+v insert, got %q expected %q", tc.config, got, tc.insert)
+		}
+		if got := d.DeleteVersionSQL(); got != tc.delete {
+			t.Errorf("%+v delete, got %q expected %q", tc.config, got, tc.delete)
+		}
+	}
+
+	d, err := NewGenericDialect(GenericConfig{
+		VersionTableDDL: "CREATE TABLE {{.Table}} (id {{.AutoIncrement}}, tstamp {{.Timestamp}})",
+		AutoIncrement:   "SERIAL",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetSchema("app", false)
+	d.SetTableName("versions")
+	if got, expected := d.CreateVersionTableSQL(), `CREATE TABLE app.versions (id SERIAL, tstamp `+DefaultGenericTimestamp+`)`; got != expected {
+		t.Errorf("ddl, got %q expected %q", got, expected)
+	}
+	d.SetQuoteIdentifiers(true)
+	if got, expected := d.CreateVersionTableSQL(), `CREATE TABLE "app"."versions" (id SERIAL, tstamp `+DefaultGenericTimestamp+`)`; got != expected {
+		t.Errorf("quoted ddl, got %q expected %q", got, expected)
+	}
+
+	for _, config := range []GenericConfig{
+		{Placeholder: "%s"},
+		{Quote: "<<>>"},
+		{VersionTableDDL: "CREATE TABLE {{.Table"},
+		{VersionTableDDL: "CREATE TABLE {{.Unknown}}"},
+	} {
+		if _, err := NewGenericDialect(config); err == nil {
+			t.Errorf("%+v, got nil expected an error", config)
+		}
+	}
+}
+
+func TestReadGenericConfig(t *testing.T) {
+	t.Parallel()
+	config, err := ReadGenericConfig(strings.NewReader(`{"placeholder": "$", "auto_increment": "SERIAL PRIMARY KEY", "alter_table_delete": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (GenericConfig{Placeholder: PlaceholderDollar, AutoIncrement: "SERIAL PRIMARY KEY", AlterTableDelete: true}); config != expected {
+		t.Errorf("config, got %+v expected %+v", config, expected)
+	}
+	if _, err := ReadGenericConfig(strings.NewReader(`{"placeholders": "$"}`)); err == nil {
+		t.Errorf("unknown field, got nil expected an error")
+	}
+}
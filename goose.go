@@ -45,22 +45,28 @@ func (p *Provider) SetBaseFS(fsys fs.FS) {
 
 // Run runs a goose command.
 func Run(command string, db *sql.DB, dir string, args ...string) error {
-	return run(command, db, dir, args)
+	return defaultProvider.RunWithOptions(command, db, dir, args)
+}
+
+// Run runs a goose command.
+func (p *Provider) Run(command string, db *sql.DB, dir string, args ...string) error {
+	return p.RunWithOptions(command, db, dir, args)
 }
 
 // RunWithOptions runs a goose command with options.
 func RunWithOptions(command string, db *sql.DB, dir string, args []string, options ...OptionsFunc) error {
-	return run(command, db, dir, args, options...)
+	return defaultProvider.RunWithOptions(command, db, dir, args, options...)
 }
 
-func run(command string, db *sql.DB, dir string, args []string, options ...OptionsFunc) error {
+// RunWithOptions runs a goose command with options.
+func (p *Provider) RunWithOptions(command string, db *sql.DB, dir string, args []string, options ...OptionsFunc) error {
 	switch command {
 	case "up":
-		if err := Up(db, dir, options...); err != nil {
+		if err := p.Up(db, dir, options...); err != nil {
 			return err
 		}
 	case "up-by-one":
-		if err := UpByOne(db, dir, options...); err != nil {
+		if err := p.UpByOne(db, dir, options...); err != nil {
 			return err
 		}
 	case "up-to":
@@ -72,7 +78,7 @@ func run(command string, db *sql.DB, dir string, args []string, options ...Optio
 		if err != nil {
 			return fmt.Errorf("version must be a number (got '%s')", args[0])
 		}
-		if err := UpTo(db, dir, version, options...); err != nil {
+		if err := p.UpTo(db, dir, version, options...); err != nil {
 			return err
 		}
 	case "create":
@@ -84,11 +90,11 @@ func run(command string, db *sql.DB, dir string, args []string, options ...Optio
 		if len(args) == 2 {
 			migrationType = args[1]
 		}
-		if err := Create(db, dir, args[0], migrationType); err != nil {
+		if err := p.Create(db, dir, args[0], migrationType); err != nil {
 			return err
 		}
 	case "down":
-		if err := Down(db, dir, options...); err != nil {
+		if err := p.Down(db, dir, options...); err != nil {
 			return err
 		}
 	case "down-to":
@@ -100,27 +106,51 @@ func run(command string, db *sql.DB, dir string, args []string, options ...Optio
 		if err != nil {
 			return fmt.Errorf("version must be a number (got '%s')", args[0])
 		}
-		if err := DownTo(db, dir, version, options...); err != nil {
+		if err := p.DownTo(db, dir, version, options...); err != nil {
 			return err
 		}
 	case "fix":
-		if err := Fix(dir); err != nil {
+		if err := p.Fix(dir, options...); err != nil {
 			return err
 		}
 	case "redo":
-		if err := Redo(db, dir, options...); err != nil {
+		if err := p.Redo(db, dir, options...); err != nil {
 			return err
 		}
 	case "reset":
-		if err := Reset(db, dir, options...); err != nil {
+		if err := p.Reset(db, dir, options...); err != nil {
 			return err
 		}
 	case "status":
-		if err := Status(db, dir, options...); err != nil {
+		if err := p.Status(db, dir, options...); err != nil {
 			return err
 		}
 	case "version":
-		if err := Version(db, dir, options...); err != nil {
+		if err := p.Version(db, dir, options...); err != nil {
+			return err
+		}
+	case "verify-db":
+		if err := p.VerifyDB(db, dir); err != nil {
+			return err
+		}
+	case "prune-orphans":
+		if _, err := p.PruneOrphans(db, dir, options...); err != nil {
+			return err
+		}
+	case "seed":
+		var set string
+		if len(args) > 0 {
+			set = args[0]
+		}
+		if err := p.Seed(db, dir, set, options...); err != nil {
+			return err
+		}
+	case "seed-reset":
+		var set string
+		if len(args) > 0 {
+			set = args[0]
+		}
+		if err := p.SeedReset(db, dir, set, options...); err != nil {
 			return err
 		}
 	default:
@@ -0,0 +1,267 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// requiresAnnotation declares the versions a SQL migration depends on, e.g. -- +goose Requires: 3, 5
+const requiresAnnotation = "+goose Requires:"
+
+// parseRequires parses the comma separated list of versions of a Requires annotation
+func parseRequires(s string) ([]int64, error) {
+	var requires []int64
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("invalid required version %q", field)
+		}
+		requires = append(requires, v)
+	}
+	return requires, nil
+}
+
+// hasRequires returns if any of the migrations declares the versions it depends on, the migrations are then
+// applied as a dependency graph instead of in the order of their versions.
+func (ms Migrations) hasRequires() bool {
+	for _, m := range ms {
+		if len(m.Requires) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dependents returns the migrations that require the version, directly or through other migrations,
+// ordered by version.
+func (ms Migrations) dependents(version int64) Migrations {
+	found := map[int64]bool{version: true}
+	for changed := true; changed; {
+		changed = false
+		for _, m := range ms {
+			if found[m.Version] {
+				continue
+			}
+			for _, r := range m.Requires {
+				if found[r] {
+					found[m.Version] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	var dependents Migrations
+	for _, m := range ms {
+		if m.Version != version && found[m.Version] {
+			dependents = append(dependents, m)
+		}
+	}
+	return dependents
+}
+
+// topologicalOrder returns the migrations ordered so that each comes after the migrations it requires, the
+// smallest version first where there is a choice. A required version that is not one of the migrations must
+// be satisfied, satisfied may be nil if none are. An ErrMigrationDependency is returned if a required version
+// is not satisfied, or the migrations require each other.
+func topologicalOrder(ms Migrations, satisfied func(version int64) bool) (Migrations, error) {
+	sorted := make(Migrations, len(ms))
+	copy(sorted, ms)
+	sort.Sort(sorted)
+
+	index := make(map[int64]int, len(sorted))
+	for i, m := range sorted {
+		index[m.Version] = i
+	}
+	waiting := make([]int, len(sorted))
+	dependents := make([][]int, len(sorted))
+	for i, m := range sorted {
+		for _, r := range m.Requires {
+			j, ok := index[r]
+			if !ok {
+				if satisfied == nil || !satisfied(r) {
+					return nil, ErrMigrationDependency{Version: m.Version, Source: m.Source, Dependency: r}
+				}
+				continue
+			}
+			waiting[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	var ready []int
+	for i := range sorted {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	ordered := make(Migrations, 0, len(sorted))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		i := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, sorted[i])
+		for _, j := range dependents[i] {
+			if waiting[j]--; waiting[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	if len(ordered) == len(sorted) {
+		return ordered, nil
+	}
+
+	// the migrations left are in a cycle, or require one; drop the ones no other migration left
+	// requires until only the cycles are left.
+	left := make(map[int]bool)
+	for i := range sorted {
+		if waiting[i] > 0 {
+			left[i] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := range left {
+			required := false
+			for _, j := range dependents[i] {
+				if left[j] {
+					required = true
+					break
+				}
+			}
+			if !required {
+				delete(left, i)
+				changed = true
+			}
+		}
+	}
+	var cycle []int64
+	for i := range sorted {
+		if left[i] {
+			cycle = append(cycle, sorted[i].Version)
+		}
+	}
+	first := sorted[index[cycle[0]]]
+	return nil, ErrMigrationDependency{Version: first.Version, Source: first.Source, Cycle: cycle}
+}
+
+// upGraph applies the migrations that have not been applied yet, each after the migrations it requires. As
+// the migrations are not applied in the order of their versions, no migration is reported as missing.
+func (p *Provider) upGraph(db *sql.DB, foundMigrations, dbMigrations Migrations, option *runState) error {
+	applied := make(map[int64]bool, len(dbMigrations))
+	for _, m := range dbMigrations {
+		applied[m.Version] = true
+	}
+	var pending Migrations
+	for _, m := range foundMigrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	ordered, err := topologicalOrder(pending, func(v int64) bool { return applied[v] })
+	if err != nil {
+		return err
+	}
+
+	current, err := p.GetDBVersion(db)
+	if err != nil {
+		return err
+	}
+	cMigration, _ := foundMigrations.Current(current)
+	if cMigration == nil {
+		cMigration = &Migration{Version: -1}
+	}
+	total := len(ordered)
+	if option.applyUpByOne && total > 1 {
+		total = 1
+	}
+	option.send(VersionCountEvent{
+		Version:           cMigration.Version,
+		VersionSource:     cMigration.Source,
+		TotalVersionsLeft: total,
+	})
+	for _, next := range ordered {
+		if err := p.applyMigration(db, next, VersionApplyEvent{
+			From:       cMigration.Version,
+			FromSource: cMigration.Source,
+			To:         next.Version,
+			ToSource:   next.Source,
+			Versioned:  true,
+		}, option); err != nil {
+			return err
+		}
+		if option.applyUpByOne {
+			return nil
+		}
+		cMigration = next
+	}
+
+	if !option.noOutput {
+		p.log.Printf("goose: no migrations to run. current version: %d\n", cMigration.Version)
+	}
+	if option.applyUpByOne {
+		return ErrNoNextVersion
+	}
+	return nil
+}
+
+// downGraph rolls back the applied migrations that require the version, directly or through other
+// migrations, each before the migrations it requires. The version itself, and the migrations that do
+// not depend on it, stay applied.
+func (p *Provider) downGraph(db *sql.DB, migrations Migrations, version int64, option *runState) error {
+	target, err := migrations.Current(version)
+	if err != nil {
+		return ErrVersionNotFound{Version: version}
+	}
+	dbMigrations, err := listAllDBVersions(p.dialect, db)
+	if err != nil {
+		return err
+	}
+	applied := make(map[int64]bool, len(dbMigrations))
+	for _, m := range dbMigrations {
+		applied[m.Version] = true
+	}
+	var rollback Migrations
+	for _, m := range migrations.dependents(target.Version) {
+		if applied[m.Version] {
+			rollback = append(rollback, m)
+		}
+	}
+	// the migrations that are not rolled back are already applied
+	ordered, err := topologicalOrder(rollback, func(int64) bool { return true })
+	if err != nil {
+		return err
+	}
+
+	option.send(VersionCountEvent{
+		Version:           target.Version,
+		VersionSource:     target.Source,
+		TotalVersionsLeft: len(ordered),
+	})
+	for i := len(ordered) - 1; i >= 0; i-- {
+		current := ordered[i]
+		apply := VersionApplyEvent{
+			From:       current.Version,
+			FromSource: current.Source,
+			Down:       true,
+			Versioned:  true,
+		}
+		if previous, err := migrations.Previous(current.Version); err == nil {
+			apply.To = previous.Version
+			apply.ToSource = previous.Source
+		}
+		if err := p.applyMigration(db, current, apply, option); err != nil {
+			return err
+		}
+	}
+	if !option.noOutput {
+		p.log.Printf("goose: no migrations depending on %d to roll back\n", target.Version)
+	}
+	return nil
+}
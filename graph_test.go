@@ -0,0 +1,154 @@
+package goose
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTopologicalOrder(t *testing.T) {
+	t.Parallel()
+	versions := func(ms Migrations) []int64 {
+		vs := make([]int64, len(ms))
+		for i, m := range ms {
+			vs[i] = m.Version
+		}
+		return vs
+	}
+	ms := Migrations{
+		{Version: 1},
+		{Version: 2, Requires: []int64{3}},
+		{Version: 3, Requires: []int64{1}},
+		{Version: 4},
+	}
+	ordered, err := topologicalOrder(ms, nil)
+	if expected := []int64{1, 3, 2, 4}; err != nil || !equalVersions(versions(ordered), expected) {
+		t.Errorf("order, got %v, %v expected %v", versions(ordered), err, expected)
+	}
+	if dependents := ms.dependents(1); !equalVersions(versions(dependents), []int64{2, 3}) {
+		t.Errorf("dependents, got %v expected [2 3]", versions(dependents))
+	}
+
+	var derr ErrMigrationDependency
+	if _, err := topologicalOrder(ms[1:], nil); !errors.As(err, &derr) || derr.Dependency != 1 {
+		t.Errorf("unknown dependency, got %v expected an ErrMigrationDependency for 1", err)
+	}
+	if _, err := topologicalOrder(ms[1:], func(v int64) bool { return v == 1 }); err != nil {
+		t.Errorf("satisfied dependency, got %v expected nil", err)
+	}
+	cycle := Migrations{
+		{Version: 1, Requires: []int64{2}},
+		{Version: 2, Requires: []int64{1}},
+		{Version: 3, Requires: []int64{2}},
+	}
+	if _, err := topologicalOrder(cycle, nil); !errors.As(err, &derr) || !equalVersions(derr.Cycle, []int64{1, 2}) {
+		t.Errorf("cycle, got %v expected an ErrMigrationDependency with the cycle [1 2]", err)
+	}
+}
+
+func TestMigrationRequires(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/00001_users.sql":    {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n")},
+		"migrations/00002_audit.sql":    {Data: []byte("-- +goose Requires: 3\n-- +goose Up\nCREATE TABLE audit (account_id INTEGER);\n-- +goose Down\nDROP TABLE audit;\n")},
+		"migrations/00003_accounts.sql": {Data: []byte("-- +goose Requires: 1\n-- +goose Up\nCREATE TABLE accounts (id INTEGER);\n-- +goose Down\nDROP TABLE accounts;\n")},
+		"migrations/00004_posts.sql":    {Data: []byte("-- +goose Requires: 1\n-- +goose Up\nCREATE TABLE posts (id INTEGER);\n-- +goose Down\nDROP TABLE posts;\n")},
+	}
+
+	t.Run("up and down-to", func(t *testing.T) {
+		t.Parallel()
+		p, db := newSQLiteProvider(t, fsys)
+
+		events := make(chan Eventer, 100)
+		if err := p.Up(db, "migrations", WithNoOutput(), WithEvents(events, false)); err != nil {
+			t.Fatal(err)
+		}
+		var applied []string
+		for e := range events {
+			if apply, ok := e.(VersionApplyEvent); ok && apply.Applied {
+				applied = append(applied, filepath.Base(apply.ToSource))
+			}
+		}
+		if expected := []string{"00001_users.sql", "00003_accounts.sql", "00002_audit.sql", "00004_posts.sql"}; !equalStrings(applied, expected) {
+			t.Errorf("applied, got %v expected %v", applied, expected)
+		}
+
+		events = make(chan Eventer, 100)
+		if err := p.DownTo(db, "migrations", 1, WithNoOutput(), WithEvents(events, false)); err != nil {
+			t.Fatal(err)
+		}
+		var rolledBack []string
+		for e := range events {
+			if apply, ok := e.(VersionApplyEvent); ok && apply.Applied {
+				rolledBack = append(rolledBack, filepath.Base(apply.FromSource))
+			}
+		}
+		if expected := []string{"00004_posts.sql", "00002_audit.sql", "00003_accounts.sql"}; !equalStrings(rolledBack, expected) {
+			t.Errorf("rolled back, got %v expected %v", rolledBack, expected)
+		}
+		if _, err := db.Exec("SELECT * FROM users"); err != nil {
+			t.Errorf("table users, expected it to still exist: %v", err)
+		}
+
+		// rolling back to accounts leaves posts, which only requires users, applied
+		if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.DownTo(db, "migrations", 3, WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("SELECT * FROM audit"); err == nil {
+			t.Errorf("table audit, expected it to be dropped")
+		}
+		if _, err := db.Exec("SELECT * FROM posts"); err != nil {
+			t.Errorf("table posts, expected it to still exist: %v", err)
+		}
+	})
+
+	t.Run("go migration", func(t *testing.T) {
+		t.Parallel()
+		p := NewProvider(Filesystem(fsys), Dialect(DialectSQLite3))
+		if err := p.AddNamedMigrationWithOptionsE("00005_go.go", nil, nil, MigrationRequires(2, 4), MigrationTags("dev")); err != nil {
+			t.Fatal(err)
+		}
+		ms, err := p.CollectMigrations("migrations", minVersion, maxVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := ms.Current(5)
+		if err != nil || len(m.Requires) != 2 || m.Requires[1] != 4 || !equalStrings(m.Tags, []string{"dev"}) {
+			t.Errorf("go migration, got %+v, %v expected it to require 2 and 4", m, err)
+		}
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		t.Parallel()
+		broken := fstest.MapFS{
+			"migrations/00001_a.sql": {Data: []byte("-- +goose Requires: 2\n-- +goose Up\nSELECT 1;\n")},
+			"migrations/00002_b.sql": {Data: []byte("-- +goose Requires: 1\n-- +goose Up\nSELECT 1;\n")},
+			"migrations/00003_c.sql": {Data: []byte("-- +goose Requires: 9\n-- +goose Up\nSELECT 1;\n")},
+		}
+		status := NewProvider(Filesystem(broken), Dialect(DialectSQLite3)).Verify("migrations")
+		codes := map[VerifyCode][]string{}
+		for _, f := range status.Findings {
+			codes[f.Code] = append(codes[f.Code], filepath.Base(f.File))
+		}
+		if expected := []string{"00001_a.sql", "00002_b.sql"}; !equalStrings(codes[VerifyCodeDependencyCycle], expected) {
+			t.Errorf("cycle findings, got %v expected %v", codes[VerifyCodeDependencyCycle], expected)
+		}
+		if expected := []string{"00003_c.sql"}; !equalStrings(codes[VerifyCodeUnknownDependency], expected) {
+			t.Errorf("unknown dependency findings, got %v expected %v", codes[VerifyCodeUnknownDependency], expected)
+		}
+		if status.Ok() {
+			t.Errorf("status, got ok expected errors")
+		}
+
+		p, db := newSQLiteProvider(t, broken)
+		var derr ErrMigrationDependency
+		err := p.Up(db, "migrations", WithNoOutput())
+		if !errors.As(err, &derr) {
+			t.Errorf("up, got %v expected an ErrMigrationDependency", err)
+		}
+	})
+}
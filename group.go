@@ -0,0 +1,255 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GroupOption configures a Provider added to a ProviderGroup
+type GroupOption func(m *groupMember)
+
+// GroupDir sets the directory of the provider's migrations, by default it is ".", see Provider.BaseDir.
+func GroupDir(dir string) GroupOption {
+	return func(m *groupMember) {
+		m.dir = dir
+	}
+}
+
+// GroupAfter makes the provider's migrations run after those of the named providers of the group.
+func GroupAfter(names ...string) GroupOption {
+	return func(m *groupMember) {
+		m.after = append(m.after, names...)
+	}
+}
+
+// groupMember is a provider of a ProviderGroup
+type groupMember struct {
+	name     string
+	provider *Provider
+	dir      string
+	after    []string
+}
+
+// ProviderGroup runs the commands across a set of named Providers, each with their own version table,
+// against the same database. The providers are run in the order they are added, unless GroupAfter
+// requires a provider to run after others; rolling back runs them in the reverse order.
+type ProviderGroup struct {
+	members []*groupMember
+}
+
+// NewProviderGroup returns an empty ProviderGroup
+func NewProviderGroup() *ProviderGroup { return &ProviderGroup{} }
+
+// Add adds the provider to the group under name, names must be unique.
+func (g *ProviderGroup) Add(name string, p *Provider, opts ...GroupOption) error {
+	if name == "" {
+		return errors.New("provider group: provider name can not be empty")
+	}
+	if p == nil {
+		return fmt.Errorf("provider group: provider %s is nil", name)
+	}
+	for _, m := range g.members {
+		if m.name == name {
+			return fmt.Errorf("provider group: provider %s already added", name)
+		}
+	}
+	m := &groupMember{name: name, provider: p, dir: "."}
+	for _, opt := range opts {
+		opt(m)
+	}
+	g.members = append(g.members, m)
+	return nil
+}
+
+// Order returns the names of the providers in the order the commands are run in. An ErrGroupDependency
+// is returned if a provider is to run after an unknown provider, or the providers depend on each other.
+func (g *ProviderGroup) Order() ([]string, error) {
+	members, err := g.ordered()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(members))
+	for i, m := range members {
+		names[i] = m.name
+	}
+	return names, nil
+}
+
+// ordered returns the members in the order the commands are run in, the order they were added in
+// as far as their dependencies allow.
+func (g *ProviderGroup) ordered() ([]*groupMember, error) {
+	index := make(map[string]int, len(g.members))
+	for i, m := range g.members {
+		index[m.name] = i
+	}
+	waiting := make([]int, len(g.members))
+	dependents := make([][]int, len(g.members))
+	for i, m := range g.members {
+		for _, after := range m.after {
+			j, ok := index[after]
+			if !ok {
+				return nil, ErrGroupDependency{Provider: m.name, Dependency: after}
+			}
+			waiting[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	var ready []int
+	for i := range g.members {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	ordered := make([]*groupMember, 0, len(g.members))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		i := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, g.members[i])
+		for _, j := range dependents[i] {
+			if waiting[j]--; waiting[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	if len(ordered) == len(g.members) {
+		return ordered, nil
+	}
+	var cycle []string
+	for i, m := range g.members {
+		if waiting[i] > 0 {
+			cycle = append(cycle, m.name)
+		}
+	}
+	return nil, ErrGroupDependency{Provider: cycle[0], Cycle: cycle}
+}
+
+// groupOptions returns the options for running a command of each provider, so the events channel is
+// only closed by the group, and if the group should close it.
+func groupOptions(opts []OptionsFunc) ([]OptionsFunc, bool) {
+	shouldClose := applyOptions(opts).shouldCloseEventsChannel()
+	opts = append(opts[:len(opts):len(opts)], func(o *options) { o.dontCloseChannel = true })
+	return opts, shouldClose
+}
+
+// each runs fn for each of the members, in order or in reverse order, stopping at the first error
+func (g *ProviderGroup) each(reverse bool, opts []OptionsFunc, fn func(m *groupMember, opts []OptionsFunc) error) error {
+	members, err := g.ordered()
+	if err != nil {
+		return err
+	}
+	opts, shouldClose := groupOptions(opts)
+	if shouldClose {
+		defer close(applyOptions(opts).eventsChannel)
+	}
+	for i := range members {
+		m := members[i]
+		if reverse {
+			m = members[len(members)-1-i]
+		}
+		if err := fn(m, opts); err != nil {
+			return ErrGroupProvider{Provider: m.name, ErrUnwrap: ErrUnwrap{err}}
+		}
+	}
+	return nil
+}
+
+// Up applies all the available migrations of each of the providers, in order.
+func (g *ProviderGroup) Up(db *sql.DB, opts ...OptionsFunc) error {
+	return g.each(false, opts, func(m *groupMember, opts []OptionsFunc) error {
+		return m.provider.Up(db, m.dir, opts...)
+	})
+}
+
+// Down rolls back the most recent migration of each of the providers, in reverse order.
+func (g *ProviderGroup) Down(db *sql.DB, opts ...OptionsFunc) error {
+	return g.each(true, opts, func(m *groupMember, opts []OptionsFunc) error {
+		return m.provider.Down(db, m.dir, opts...)
+	})
+}
+
+// Reset rolls back all the migrations of each of the providers, in reverse order.
+func (g *ProviderGroup) Reset(db *sql.DB, opts ...OptionsFunc) error {
+	return g.each(true, opts, func(m *groupMember, opts []OptionsFunc) error {
+		return m.provider.Reset(db, m.dir, opts...)
+	})
+}
+
+// Fix renames the timestamp based migrations of each of the providers into sequential migrations, in order.
+func (g *ProviderGroup) Fix(opts ...OptionsFunc) error {
+	return g.each(false, opts, func(m *groupMember, opts []OptionsFunc) error {
+		return m.provider.Fix(m.dir, opts...)
+	})
+}
+
+// Status prints the combined status of the migrations of all the providers, in order, with the
+// provider each migration belongs to. The StatusEvents sent have their Provider set.
+func (g *ProviderGroup) Status(db *sql.DB, opts ...OptionsFunc) error {
+	members, err := g.ordered()
+	if err != nil {
+		return err
+	}
+	option := applyOptions(opts)
+	if option.shouldCloseEventsChannel() {
+		defer close(option.eventsChannel)
+	}
+	width := len("Provider")
+	for _, m := range members {
+		if len(m.name) > width {
+			width = len(m.name)
+		}
+	}
+	if !option.noOutput && len(members) > 0 {
+		logger := members[0].provider.log
+		logger.Printf("    %-*s   Applied At                  Migration\n", width, "Provider")
+		logger.Printf("    %s\n", strings.Repeat("=", width+42))
+	}
+	for _, m := range members {
+		memberOption := *option
+		memberOption.bus = m.provider.events
+		err := m.provider.eventsStatus(db, m.dir, &memberOption, func(current StatusEvent) {
+			current.Provider = m.name
+			memberOption.send(current)
+			if !option.noOutput {
+				m.provider.log.Printf("    %-*s   %-24s -- %v\n", width, m.name, current.AppliedString(), current.Script())
+			}
+		})
+		if errors.As(err, &ErrNotInitialized{}) {
+			if !option.noOutput {
+				m.provider.log.Printf("    %-*s   not initialized, version table %s does not exist\n", width, m.name, m.provider.tableName)
+			}
+			continue
+		}
+		if err != nil {
+			return ErrGroupProvider{Provider: m.name, ErrUnwrap: ErrUnwrap{err}}
+		}
+	}
+	return nil
+}
+
+// Verify checks the migrations of each of the providers, the findings of all the providers are combined.
+// The order of the providers is checked as well.
+func (g *ProviderGroup) Verify() VerifyStatus {
+	members, err := g.ordered()
+	if err != nil {
+		return VerifyStatus{Status: VerifyStatusErr, Error: err}
+	}
+	var (
+		combined VerifyStatus
+		errs     []error
+	)
+	for _, m := range members {
+		status := m.provider.Verify(m.dir)
+		combined.Status |= status.Status
+		combined.Findings = append(combined.Findings, status.Findings...)
+		if status.Error != nil {
+			errs = append(errs, ErrGroupProvider{Provider: m.name, ErrUnwrap: ErrUnwrap{status.Error}})
+		}
+	}
+	combined.Error = errors.Join(errs...)
+	return combined
+}
@@ -0,0 +1,120 @@
+package goose
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProviderGroup(t *testing.T) {
+	t.Parallel()
+
+	newProvider := func(table string, fsys fstest.MapFS) *Provider {
+		return NewProvider(Filesystem(fsys), Dialect(DialectSQLite3), Tablename(table))
+	}
+	users := newProvider("users_db_version", fstest.MapFS{
+		"00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n")},
+	})
+	posts := newProvider("posts_db_version", fstest.MapFS{
+		"00001_posts.sql": {Data: []byte("-- +goose Up\nCREATE TABLE posts (id INTEGER, user_id INTEGER REFERENCES users(id));\n-- +goose Down\nDROP TABLE posts;\n")},
+		"00002_tags.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE tags (post_id INTEGER REFERENCES posts(id));\n-- +goose Down\nDROP TABLE tags;\n")},
+	})
+
+	t.Run("order", func(t *testing.T) {
+		t.Parallel()
+		group := NewProviderGroup()
+		for _, add := range []struct {
+			name  string
+			after []string
+		}{{"c", []string{"b"}}, {"a", nil}, {"b", []string{"a"}}, {"d", nil}} {
+			if err := group.Add(add.name, users, GroupAfter(add.after...)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := group.Add("a", users); err == nil {
+			t.Errorf("duplicate name, got nil expected an error")
+		}
+		order, err := group.Order()
+		if expected := []string{"a", "b", "c", "d"}; err != nil || !equalStrings(order, expected) {
+			t.Errorf("order, got %v, %v expected %v", order, err, expected)
+		}
+
+		if err := group.Add("e", users, GroupAfter("missing")); err != nil {
+			t.Fatal(err)
+		}
+		var derr ErrGroupDependency
+		if _, err := group.Order(); !errors.As(err, &derr) || derr.Dependency != "missing" {
+			t.Errorf("unknown dependency, got %v expected an ErrGroupDependency", err)
+		}
+
+		cycle := NewProviderGroup()
+		_ = cycle.Add("a", users, GroupAfter("b"))
+		_ = cycle.Add("b", users, GroupAfter("a"))
+		if status := cycle.Verify(); !errors.As(status.Error, &derr) || !equalStrings(derr.Cycle, []string{"a", "b"}) {
+			t.Errorf("cycle, got %v expected an ErrGroupDependency with a cycle", status.Error)
+		}
+	})
+
+	t.Run("up status and reset", func(t *testing.T) {
+		t.Parallel()
+		db := openSQLite(t)
+		// posts is added first, but its tables reference users
+		group := NewProviderGroup()
+		if err := group.Add("posts", posts, GroupAfter("users")); err != nil {
+			t.Fatal(err)
+		}
+		if err := group.Add("users", users); err != nil {
+			t.Fatal(err)
+		}
+
+		events := make(chan Eventer, 100)
+		if err := group.Up(db, WithNoOutput(), WithEvents(events, false)); err != nil {
+			t.Fatal(err)
+		}
+		var applied []string
+		for e := range events {
+			if apply, ok := e.(VersionApplyEvent); ok && apply.Applied {
+				applied = append(applied, apply.ToSource)
+			}
+		}
+		if expected := []string{"00001_users.sql", "00001_posts.sql", "00002_tags.sql"}; !equalStrings(applied, expected) {
+			t.Errorf("applied, got %v expected %v", applied, expected)
+		}
+
+		events = make(chan Eventer, 100)
+		if err := group.Status(db, WithNoOutput(), WithEvents(events, false)); err != nil {
+			t.Fatal(err)
+		}
+		var status []string
+		for e := range events {
+			if se, ok := e.(StatusEvent); ok && !se.AppliedAt.IsZero() {
+				status = append(status, se.Provider+"/"+se.Source)
+			}
+		}
+		if expected := []string{"users/00001_users.sql", "posts/00001_posts.sql", "posts/00002_tags.sql"}; !equalStrings(status, expected) {
+			t.Errorf("status, got %v expected %v", status, expected)
+		}
+
+		if err := group.Reset(db, WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("SELECT * FROM users"); err == nil {
+			t.Errorf("table users, expected it to be dropped")
+		}
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		t.Parallel()
+		broken := newProvider("broken_db_version", fstest.MapFS{
+			"00001_broken.sql": {Data: []byte("-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n")},
+		})
+		group := NewProviderGroup()
+		_ = group.Add("users", users)
+		_ = group.Add("broken", broken)
+		status := group.Verify()
+		var perr ErrGroupProvider
+		if status.Ok() || !errors.As(status.Error, &perr) || perr.Provider != "broken" {
+			t.Errorf("verify, got %+v expected an error for broken", status)
+		}
+	})
+}
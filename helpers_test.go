@@ -1,6 +1,9 @@
 package goose
 
 import (
+	"database/sql"
+	"io/fs"
+	"path/filepath"
 	"testing"
 )
 
@@ -25,3 +28,46 @@ func TestCamelSnake(t *testing.T) {
 		}
 	}
 }
+
+// openSQLite opens a new sqlite database in the test's temporary directory, it is closed when the test ends
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "goose.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newSQLiteProvider returns a provider of the sqlite dialect with the migrations of fsys, along with a new
+// database, the options are applied after the filesystem and dialect
+func newSQLiteProvider(t *testing.T, fsys fs.FS, opts ...providerOptions) (*Provider, *sql.DB) {
+	t.Helper()
+	opts = append([]providerOptions{Filesystem(fsys), Dialect(DialectSQLite3)}, opts...)
+	return NewProvider(opts...), openSQLite(t)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalVersions(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,481 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+)
+
+// HistoryDialect is implemented by the dialects that record the history of the migrations applied and rolled
+// back, all the dialects of goose do. CreateHistoryTableSQL creates the table, with the version_id, is_applied,
+// applied_by, duration_ms and tstamp columns; InsertHistorySQL records the version, whether it was applied or
+// rolled back, who ran it and how long it took in milliseconds; HistoryQuerySQL retrieves the version_id,
+// is_applied, tstamp, applied_by and duration_ms of each record, in the order they were recorded; and
+// VersionHistorySQL retrieves the version_id, is_applied and tstamp of each row of the version table, in the
+// order they were inserted.
+type HistoryDialect interface {
+	CreateHistoryTableSQL() string
+	InsertHistorySQL() string
+	HistoryQuerySQL() string
+	VersionHistorySQL() string
+}
+
+// HistoryEntry is a migration applied or rolled back, see Provider.History
+type HistoryEntry struct {
+	Version   int64     `json:"version"`
+	Direction Direction `json:"direction"`
+	Timestamp time.Time `json:"timestamp"`
+	// AppliedBy is who ran the migration and Duration how long it took, they are only known for the
+	// migrations run once the history was recorded
+	AppliedBy string        `json:"applied_by,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+}
+
+// historyFilter are the entries History returns
+type historyFilter struct {
+	version      int64
+	since, until time.Time
+}
+
+// HistoryOption filters the entries returned by History
+type HistoryOption func(f *historyFilter)
+
+// HistoryVersion only returns the entries of the version
+func HistoryVersion(version int64) HistoryOption {
+	return func(f *historyFilter) { f.version = version }
+}
+
+// HistorySince only returns the entries at or after t
+func HistorySince(t time.Time) HistoryOption {
+	return func(f *historyFilter) { f.since = t }
+}
+
+// HistoryUntil only returns the entries before t
+func HistoryUntil(t time.Time) HistoryOption {
+	return func(f *historyFilter) { f.until = t }
+}
+
+// WithHistory records the migrations applied and rolled back by the command in the history table, which is
+// created if it does not exist. Without it the history table is neither created nor written to.
+func WithHistory() OptionsFunc {
+	return func(o *options) { o.history = true }
+}
+
+// WithAppliedBy records name as who ran the migrations in the history, by default it is the user running goose
+func WithAppliedBy(name string) OptionsFunc {
+	return func(o *options) { o.appliedBy = name }
+}
+
+// currentUser returns the name of the user running goose, empty if it is not known
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// ensureHistoryTable returns if the history is recorded, the history table is created if it does not exist
+func (p *Provider) ensureHistoryTable(db *sql.DB) (bool, error) {
+	hd, ok := p.dialect.(HistoryDialect)
+	if !ok {
+		return false, nil
+	}
+	exists, err := p.tableExists(db, HistoryTableSuffix, func() (*sql.Rows, error) { return db.Query(hd.HistoryQuerySQL()) })
+	if err != nil {
+		return false, fmt.Errorf("failed to query history: %w", err)
+	}
+	if exists {
+		return true, nil
+	}
+	if _, err := db.Exec(hd.CreateHistoryTableSQL()); err != nil {
+		return false, fmt.Errorf("failed to create history table: %w", err)
+	}
+	return true, nil
+}
+
+// recordHistory records the migration was applied, or rolled back, in the history, if it is recorded for the command
+func (p *Provider) recordHistory(fn func(string, ...interface{}) (sql.Result, error), m *Migration, direction bool, option *runState) error {
+	if option == nil || !option.recordHistory {
+		return nil
+	}
+	appliedBy := option.appliedBy
+	if appliedBy == "" {
+		appliedBy = currentUser()
+	}
+	duration := time.Since(option.migrationStart).Milliseconds()
+	if _, err := p.execQuery(fn, p.dialect.(HistoryDialect).InsertHistorySQL(), m.Version, direction, appliedBy, duration); err != nil {
+		return fmt.Errorf("failed to insert goose history: %w", err)
+	}
+	return nil
+}
+
+// History returns the migrations applied and rolled back, see Provider.History
+func History(db *sql.DB, opts ...HistoryOption) ([]HistoryEntry, error) {
+	return defaultProvider.History(db, opts...)
+}
+
+// History returns an entry for each row of the version table, in the order they were inserted, filtered by the
+// options. Who ran each migration and how long it took are taken from the history table, for the migrations
+// run with the history recorded, see WithHistory. Nothing is created if the tables do not exist, an
+// ErrNotInitialized is returned if the version table does not.
+func (p *Provider) History(db *sql.DB, opts ...HistoryOption) ([]HistoryEntry, error) {
+	var filter historyFilter
+	for _, opt := range opts {
+		opt(&filter)
+	}
+
+	exists, err := p.tableExists(db, "", func() (*sql.Rows, error) { return p.dialect.DBVersionQuery(db) })
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the version table: %w", err)
+	}
+	if !exists {
+		return nil, ErrNotInitialized{Table: p.tableName, ErrUnwrap: ErrUnwrap{errTableMissing}}
+	}
+	history, err := p.versionHistory(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.addRecordedHistory(db, history); err != nil {
+		return nil, err
+	}
+
+	filtered := history[:0]
+	for _, entry := range history {
+		switch {
+		case filter.version != 0 && entry.Version != filter.version:
+		case !filter.since.IsZero() && entry.Timestamp.Before(filter.since):
+		case !filter.until.IsZero() && !entry.Timestamp.Before(filter.until):
+		default:
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+// versionHistory returns an entry for each row of the version table but the initial version 0 row, in the order
+// they were inserted. For the dialects that do not implement HistoryDialect the rows are read with DBVersionQuery,
+// with the time the version was last applied at.
+func (p *Provider) versionHistory(db *sql.DB) ([]HistoryEntry, error) {
+	hd, ok := p.dialect.(HistoryDialect)
+	if !ok {
+		return p.latestVersionHistory(db)
+	}
+	rows, err := db.Query(hd.VersionHistorySQL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the version table: %w", err)
+	}
+	defer rows.Close()
+	var history []HistoryEntry
+	for rows.Next() {
+		var (
+			entry     HistoryEntry
+			isApplied bool
+		)
+		if err := rows.Scan(&entry.Version, &isApplied, &entry.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if entry.Version == 0 {
+			continue
+		}
+		entry.Direction = directionOf(isApplied)
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get next row: %w", err)
+	}
+	return history, nil
+}
+
+// latestVersionHistory returns the entries of versionHistory from DBVersionQuery, which does not read the
+// time of the rows, so each is given the time its version was last applied at.
+func (p *Provider) latestVersionHistory(db *sql.DB) ([]HistoryEntry, error) {
+	rows, err := p.dialect.DBVersionQuery(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the version table: %w", err)
+	}
+	var history []HistoryEntry
+	for rows.Next() {
+		var row MigrationRecord
+		if err := rows.Scan(&row.VersionID, &row.IsApplied); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if row.VersionID != 0 {
+			history = append(history, HistoryEntry{Version: row.VersionID, Direction: directionOf(row.IsApplied)})
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	q := p.dialect.MigrationSQL()
+	for i := range history {
+		var isApplied bool
+		if err := db.QueryRow(q, history[i].Version).Scan(&history[i].Timestamp, &isApplied); err != nil {
+			return nil, fmt.Errorf("failed to query the latest migration: %w", err)
+		}
+	}
+	// DBVersionQuery returns the most recent row first
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
+
+// addRecordedHistory sets who ran the migration of each entry, and how long it took, from the history table. An
+// entry is matched with the records of its version and direction, the most recent entry with the most recent
+// record, as rolling back a migration deletes the earlier rows of its version.
+func (p *Provider) addRecordedHistory(db *sql.DB, history []HistoryEntry) error {
+	hd, ok := p.dialect.(HistoryDialect)
+	if !ok {
+		return nil
+	}
+	// the history table does not exist until a migration is run with the history recorded
+	query := func() (*sql.Rows, error) { return db.Query(hd.HistoryQuerySQL()) }
+	exists, err := p.tableExists(db, HistoryTableSuffix, query)
+	if err != nil {
+		return fmt.Errorf("failed to query history: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+	rows, err := query()
+	if err != nil {
+		return fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+	type key struct {
+		version   int64
+		direction Direction
+	}
+	recorded := make(map[key][]HistoryEntry)
+	for rows.Next() {
+		var (
+			entry     HistoryEntry
+			isApplied bool
+			duration  int64
+		)
+		if err := rows.Scan(&entry.Version, &isApplied, &entry.Timestamp, &entry.AppliedBy, &duration); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		entry.Duration = time.Duration(duration) * time.Millisecond
+		k := key{entry.Version, directionOf(isApplied)}
+		recorded[k] = append(recorded[k], entry)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get next row: %w", err)
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		k := key{history[i].Version, history[i].Direction}
+		if records := recorded[k]; len(records) > 0 {
+			last := records[len(records)-1]
+			history[i].AppliedBy, history[i].Duration = last.AppliedBy, last.Duration
+			recorded[k] = records[:len(records)-1]
+		}
+	}
+	return nil
+}
+
+func (d PostgresDialect) CreateHistoryTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+            	id serial NOT NULL,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                applied_by varchar(255) NOT NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, d.table(HistoryTableSuffix))
+}
+
+func (d PostgresDialect) InsertHistorySQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, applied_by, duration_ms) VALUES ($1, $2, $3, $4);", d.table(HistoryTableSuffix))
+}
+
+func (d PostgresDialect) HistoryQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp, applied_by, duration_ms FROM %s ORDER BY id", d.table(HistoryTableSuffix))
+}
+
+func (d PostgresDialect) VersionHistorySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id", d.table(""))
+}
+
+func (d MySQLDialect) CreateHistoryTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                id serial NOT NULL,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                applied_by varchar(255) NOT NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, d.table(HistoryTableSuffix))
+}
+
+func (d MySQLDialect) InsertHistorySQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, applied_by, duration_ms) VALUES (?, ?, ?, ?);", d.table(HistoryTableSuffix))
+}
+
+func (d MySQLDialect) HistoryQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp, applied_by, duration_ms FROM %s ORDER BY id", d.table(HistoryTableSuffix))
+}
+
+func (d MySQLDialect) VersionHistorySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id", d.table(""))
+}
+
+func (d SqlServerDialect) CreateHistoryTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                id INT NOT NULL IDENTITY(1,1) PRIMARY KEY,
+                version_id BIGINT NOT NULL,
+                is_applied BIT NOT NULL,
+                applied_by NVARCHAR(255) NOT NULL,
+                duration_ms BIGINT NOT NULL,
+                tstamp DATETIME NULL DEFAULT CURRENT_TIMESTAMP
+            );`, d.table(HistoryTableSuffix))
+}
+
+func (d SqlServerDialect) InsertHistorySQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, applied_by, duration_ms) VALUES (@p1, @p2, @p3, @p4);", d.table(HistoryTableSuffix))
+}
+
+func (d SqlServerDialect) HistoryQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp, applied_by, duration_ms FROM %s ORDER BY id", d.table(HistoryTableSuffix))
+}
+
+func (d SqlServerDialect) VersionHistorySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id", d.table(""))
+}
+
+func (d Sqlite3Dialect) CreateHistoryTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                version_id INTEGER NOT NULL,
+                is_applied INTEGER NOT NULL,
+                applied_by TEXT NOT NULL,
+                duration_ms INTEGER NOT NULL,
+                tstamp TIMESTAMP DEFAULT (datetime('now'))
+            );`, d.table(HistoryTableSuffix))
+}
+
+func (d Sqlite3Dialect) InsertHistorySQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, applied_by, duration_ms) VALUES (?, ?, ?, ?);", d.table(HistoryTableSuffix))
+}
+
+func (d Sqlite3Dialect) HistoryQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp, applied_by, duration_ms FROM %s ORDER BY id", d.table(HistoryTableSuffix))
+}
+
+func (d Sqlite3Dialect) VersionHistorySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id", d.table(""))
+}
+
+func (d RedshiftDialect) CreateHistoryTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+            	id integer NOT NULL identity(1, 1),
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                applied_by varchar(255) NOT NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default sysdate,
+                PRIMARY KEY(id)
+            );`, d.table(HistoryTableSuffix))
+}
+
+func (d RedshiftDialect) InsertHistorySQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, applied_by, duration_ms) VALUES ($1, $2, $3, $4);", d.table(HistoryTableSuffix))
+}
+
+func (d RedshiftDialect) HistoryQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp, applied_by, duration_ms FROM %s ORDER BY id", d.table(HistoryTableSuffix))
+}
+
+func (d RedshiftDialect) VersionHistorySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id", d.table(""))
+}
+
+func (d TiDBDialect) CreateHistoryTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                applied_by varchar(255) NOT NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, d.table(HistoryTableSuffix))
+}
+
+func (d TiDBDialect) InsertHistorySQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, applied_by, duration_ms) VALUES (?, ?, ?, ?);", d.table(HistoryTableSuffix))
+}
+
+func (d TiDBDialect) HistoryQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp, applied_by, duration_ms FROM %s ORDER BY id", d.table(HistoryTableSuffix))
+}
+
+func (d TiDBDialect) VersionHistorySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id", d.table(""))
+}
+
+func (d ClickHouseDialect) CreateHistoryTableSQL() string {
+	return d.createTableSQL(d.table(HistoryTableSuffix), `
+      id Int64 default toUnixTimestamp64Nano(now64(9)),
+      version_id Int64,
+      is_applied UInt8,
+      applied_by String,
+      duration_ms Int64,
+      date Date default now(),
+      tstamp DateTime default now()`, "id")
+}
+
+func (d ClickHouseDialect) InsertHistorySQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, applied_by, duration_ms) VALUES ($1, $2, $3, $4)", d.table(HistoryTableSuffix))
+}
+
+func (d ClickHouseDialect) HistoryQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp, applied_by, duration_ms FROM %s ORDER BY id", d.table(HistoryTableSuffix))
+}
+
+func (d ClickHouseDialect) VersionHistorySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id", d.table(""))
+}
+
+func (d *GenericDialect) CreateHistoryTableSQL() string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+    id %s,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    applied_by VARCHAR(255) NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    tstamp %s
+)`, d.table(HistoryTableSuffix), d.config.AutoIncrement, d.config.Timestamp)
+}
+
+func (d *GenericDialect) InsertHistorySQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, applied_by, duration_ms) VALUES (%s, %s, %s, %s)",
+		d.table(HistoryTableSuffix), d.ph(1), d.ph(2), d.ph(3), d.ph(4))
+}
+
+func (d *GenericDialect) HistoryQuerySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp, applied_by, duration_ms FROM %s ORDER BY id", d.table(HistoryTableSuffix))
+}
+
+func (d *GenericDialect) VersionHistorySQL() string {
+	return fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY %s", d.table(""), d.config.OrderBy)
+}
+
+var (
+	_ HistoryDialect = PostgresDialect{}
+	_ HistoryDialect = MySQLDialect{}
+	_ HistoryDialect = SqlServerDialect{}
+	_ HistoryDialect = Sqlite3Dialect{}
+	_ HistoryDialect = RedshiftDialect{}
+	_ HistoryDialect = TiDBDialect{}
+	_ HistoryDialect = ClickHouseDialect{}
+	_ HistoryDialect = (*GenericDialect)(nil)
+)
@@ -0,0 +1,121 @@
+package goose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	std "log"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/00001_users.sql":    {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n")},
+		"migrations/00002_accounts.sql": {Data: []byte("-- +goose Up\nCREATE TABLE accounts (id INTEGER);\n-- +goose Down\nDROP TABLE accounts;\n")},
+	}
+	var buff bytes.Buffer
+	p, db := newSQLiteProvider(t, fsys, Log(std.New(&buff, "", 0)))
+
+	if _, err := p.History(db); !errors.As(err, &ErrNotInitialized{}) {
+		t.Fatalf("history of an empty database, got %v expected ErrNotInitialized", err)
+	}
+
+	if err := p.Up(db, "migrations", WithHistory(), WithAppliedBy("alice")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Down(db, "migrations", WithHistory(), WithAppliedBy("bob")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Up(db, "migrations", WithHistory(), WithAppliedBy("carol")); err != nil {
+		t.Fatal(err)
+	}
+
+	describe := func(entries []HistoryEntry) []string {
+		s := make([]string, len(entries))
+		for i, e := range entries {
+			s[i] = fmt.Sprintf("%d %s %s", e.Version, e.Direction, e.AppliedBy)
+		}
+		return s
+	}
+	entries, err := p.History(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rolling back 2 deleted its row, the row of 2 applied again is matched with carol's record
+	if got, expected := describe(entries), []string{"1 up alice", "2 up carol"}; !equalStrings(got, expected) {
+		t.Errorf("history, got %v expected %v", got, expected)
+	}
+	for _, e := range entries {
+		if e.Timestamp.IsZero() {
+			t.Errorf("version %d %s, expected a timestamp", e.Version, e.Direction)
+		}
+	}
+
+	entries, err = p.History(db, HistoryVersion(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := describe(entries), []string{"2 up carol"}; !equalStrings(got, expected) {
+		t.Errorf("history, got %v expected %v", got, expected)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opt      HistoryOption
+		expected int
+	}{
+		{"since the future", HistorySince(time.Now().Add(time.Hour)), 0},
+		{"since the past", HistorySince(time.Now().Add(-time.Hour)), 2},
+		{"until the past", HistoryUntil(time.Now().Add(-time.Hour)), 0},
+		{"until the future", HistoryUntil(time.Now().Add(time.Hour)), 2},
+	} {
+		entries, err := p.History(db, tc.opt)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(entries) != tc.expected {
+			t.Errorf("%s, got %d entries expected %d", tc.name, len(entries), tc.expected)
+		}
+	}
+
+	// without the history table the entries are only the rows of the version table
+	if _, err := db.Exec("DROP TABLE " + TableName() + HistoryTableSuffix); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = p.History(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := describe(entries), []string{"1 up ", "2 up "}; !equalStrings(got, expected) {
+		t.Errorf("history, got %v expected %v", got, expected)
+	}
+
+	// without WithHistory the history table is not created again
+	if err := p.Down(db, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM sqlite_master WHERE name = ?", TableName()+HistoryTableSuffix).Scan(&count); err != nil || count != 0 {
+		t.Errorf("history table, got %d, %v expected it not to exist", count, err)
+	}
+	entries, err = p.History(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := describe(entries), []string{"1 up "}; !equalStrings(got, expected) {
+		t.Errorf("history, got %v expected %v", got, expected)
+	}
+}
+
+func TestHistoryQueryError(t *testing.T) {
+	t.Parallel()
+	p, db := newSQLiteProvider(t, fstest.MapFS{})
+	db.Close()
+	_, err := p.History(db)
+	if err == nil || errors.As(err, &ErrNotInitialized{}) {
+		t.Errorf("history of a closed database, got %v expected a query error", err)
+	}
+}
@@ -0,0 +1,102 @@
+package goose
+
+import (
+	"database/sql"
+)
+
+const (
+	hookBeforeAll  = "BeforeAll"
+	hookAfterAll   = "AfterAll"
+	hookBeforeEach = "BeforeEach"
+	hookAfterEach  = "AfterEach"
+)
+
+// HookContext is what a migration hook is called with
+type HookContext struct {
+	// Migration is the migration being run, nil for the BeforeAll and AfterAll hooks
+	Migration *Migration
+	Direction Direction
+	DB        *sql.DB
+	// Tx is the transaction the migration is run in, nil if the migration is not run in a transaction.
+	// Always nil for the BeforeAll and AfterAll hooks
+	Tx *sql.Tx
+}
+
+// HookFunc is a migration hook, returning an error aborts the migration, rolling it back if
+// it is run in a transaction.
+type HookFunc func(hc HookContext) error
+
+// BeforeEach adds a hook that is run before each migration, in the migration's transaction
+func BeforeEach(fn HookFunc) func(p *Provider) {
+	return func(p *Provider) {
+		p.beforeEach = append(p.beforeEach, fn)
+	}
+}
+
+// AfterEach adds a hook that is run after each migration, in the migration's transaction
+func AfterEach(fn HookFunc) func(p *Provider) {
+	return func(p *Provider) {
+		p.afterEach = append(p.afterEach, fn)
+	}
+}
+
+// BeforeAll adds a hook that is run by a command before it runs its first migration
+func BeforeAll(fn HookFunc) func(p *Provider) {
+	return func(p *Provider) {
+		p.beforeAll = append(p.beforeAll, fn)
+	}
+}
+
+// AfterAll adds a hook that is run by a command after all its migrations have been run successfully,
+// it is not run if the command did not run any migrations.
+func AfterAll(fn HookFunc) func(p *Provider) {
+	return func(p *Provider) {
+		p.afterAll = append(p.afterAll, fn)
+	}
+}
+
+// runHooks calls the hooks in order, stopping at the first one to fail
+func runHooks(name string, hooks []HookFunc, hc HookContext) error {
+	for _, fn := range hooks {
+		if err := fn(hc); err != nil {
+			return ErrHook{Hook: name, ErrUnwrap: ErrUnwrap{err}}
+		}
+	}
+	return nil
+}
+
+// runEachHooks runs the BeforeEach, or AfterEach, hooks for the migration, tx may be nil. When the SQL
+// callbacks are run in the migration's transaction, the matching callback is run before the hooks.
+func (p *Provider) runEachHooks(name string, m *Migration, direction bool, db *sql.DB, tx *sql.Tx, option *runState) error {
+	hooks, callback := p.beforeEach, CallbackBeforeEachMigrate
+	if name == hookAfterEach {
+		hooks, callback = p.afterEach, CallbackAfterEachMigrate
+	}
+	if p.sqlCallbacksInTx && option != nil {
+		if err := p.runSQLCallback(callback, option.callbacks, direction, db, tx); err != nil {
+			return err
+		}
+	}
+	return runHooks(name, hooks, HookContext{
+		Migration: m,
+		Direction: directionOf(direction),
+		DB:        db,
+		Tx:        tx,
+	})
+}
+
+// finishCommand runs the AfterAll hooks, if the command ran any migrations successfully, and sends the
+// CommandSummaryEvent. It returns the error the command should return.
+func (p *Provider) finishCommand(db *sql.DB, option *runState, command string, down bool, err error) error {
+	if err == nil && option.applied > 0 {
+		err = runHooks(hookAfterAll, p.afterAll, HookContext{
+			Direction: directionOf(!down),
+			DB:        db,
+		})
+		if err == nil {
+			err = p.runSQLCallback(CallbackAfterMigrate, option.callbacks, !down, db, nil)
+		}
+	}
+	option.sendSummary(command, down, err)
+	return err
+}
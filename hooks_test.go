@@ -0,0 +1,108 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql":     {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/00002_b.tpl.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+		"migrations/00004_d.sql":     {Data: []byte("-- +goose Up\n-- +goose NO TRANSACTION\nCREATE TABLE d (id INTEGER);\n-- +goose Down\nDROP TABLE d;\n")},
+	}
+
+	var (
+		calls   []string
+		failOn  string
+		failErr = errors.New("hook failed")
+	)
+	record := func(name string) HookFunc {
+		return func(hc HookContext) error {
+			call := fmt.Sprintf("%s %s", name, hc.Direction)
+			if hc.Migration != nil {
+				call = fmt.Sprintf("%s %d tx:%v", call, hc.Migration.Version, hc.Tx != nil)
+			}
+			calls = append(calls, call)
+			if call == failOn {
+				return failErr
+			}
+			return nil
+		}
+	}
+	p, db := newSQLiteProvider(t, fsys,
+		BeforeAll(record("BeforeAll")),
+		BeforeEach(record("BeforeEach")),
+		AfterEach(record("AfterEach")),
+		AfterAll(record("AfterAll")),
+	)
+	p.AddNamedMigration("00003_c.go", func(tx *sql.Tx) error {
+		_, err := tx.Exec("CREATE TABLE c (id INTEGER)")
+		return err
+	}, func(tx *sql.Tx) error {
+		_, err := tx.Exec("DROP TABLE c")
+		return err
+	})
+
+	if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"BeforeAll up",
+		"BeforeEach up 1 tx:true", "AfterEach up 1 tx:true",
+		"BeforeEach up 2 tx:true", "AfterEach up 2 tx:true",
+		"BeforeEach up 3 tx:true", "AfterEach up 3 tx:true",
+		"BeforeEach up 4 tx:false", "AfterEach up 4 tx:false",
+		"AfterAll up",
+	}
+	if !equalStrings(calls, expected) {
+		t.Errorf("up calls, got %q expected %q", calls, expected)
+	}
+
+	t.Run("no migrations", func(t *testing.T) {
+		calls = nil
+		if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) != 0 {
+			t.Errorf("calls, got %q expected none", calls)
+		}
+	})
+
+	t.Run("failing hook rolls back", func(t *testing.T) {
+		calls = nil
+		failOn = "AfterEach down 3 tx:true"
+		err := p.DownTo(db, "migrations", 0, WithNoOutput())
+		var hookErr ErrHook
+		if !errors.As(err, &hookErr) || hookErr.Hook != "AfterEach" || !errors.Is(err, failErr) {
+			t.Fatalf("error, got %v expected AfterEach ErrHook", err)
+		}
+		var merr MigrationError
+		if !errors.As(err, &merr) || merr.Version != 3 || !merr.RolledBack {
+			t.Errorf("migration error, got %+v expected version 3 rolled back", merr)
+		}
+		expected := []string{
+			"BeforeAll down",
+			"BeforeEach down 4 tx:false", "AfterEach down 4 tx:false",
+			"BeforeEach down 3 tx:true", "AfterEach down 3 tx:true",
+		}
+		if !equalStrings(calls, expected) {
+			t.Errorf("down calls, got %q expected %q", calls, expected)
+		}
+		version, err := p.GetDBVersion(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 3 {
+			t.Errorf("version, got %v expected 3", version)
+		}
+		if _, err := db.Exec("SELECT * FROM c"); err != nil {
+			t.Errorf("table c, expected the drop to be rolled back: %v", err)
+		}
+	})
+}
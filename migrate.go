@@ -24,14 +24,9 @@ var (
 type Migrations []*Migration
 
 // helpers so we can use pkg sort
-func (ms Migrations) Len() int      { return len(ms) }
-func (ms Migrations) Swap(i, j int) { ms[i], ms[j] = ms[j], ms[i] }
-func (ms Migrations) Less(i, j int) bool {
-	if ms[i].Version == ms[j].Version {
-		panic(fmt.Sprintf("goose: duplicate version %v detected:\n%v\n%v", ms[i].Version, ms[i].Source, ms[j].Source))
-	}
-	return ms[i].Version < ms[j].Version
-}
+func (ms Migrations) Len() int           { return len(ms) }
+func (ms Migrations) Swap(i, j int)      { ms[i], ms[j] = ms[j], ms[i] }
+func (ms Migrations) Less(i, j int) bool { return ms[i].Version < ms[j].Version }
 
 // Current gets the current migration.
 func (ms Migrations) Current(current int64) (*Migration, error) {
@@ -101,10 +96,20 @@ func (ms Migrations) NumberOfMigrations(current int64, down bool) int {
 // the given to migration. If the `to` migration does not exist it will be the migration that would be the next
 // on in line of the migrations depending on if the trend is going up or down, e.g. if `to - from > 0` then it's going
 // up if `to - from < 0` then it's going down. If `to - from == 0`, zero is returned.
-// if from migration is not found this function will panic
+// if from migration is not found this function will panic, use NumberOfMigrationsToE to get an error instead.
 func (ms Migrations) NumberOfMigrationsTo(from int64, to int64) int {
+	n, err := ms.NumberOfMigrationsToE(from, to)
+	if err != nil {
+		panic(err.Error())
+	}
+	return n
+}
+
+// NumberOfMigrationsToE returns the number of migrations that could be applied from the starting migration to
+// the given to migration, see NumberOfMigrationsTo. If from migration is not found an ErrVersionNotFound is returned.
+func (ms Migrations) NumberOfMigrationsToE(from int64, to int64) (int, error) {
 	if to-from == 0 {
-		return 0
+		return 0, nil
 	}
 	down := to-from < 0
 	idxTo, idxFrom := -1, -1
@@ -123,7 +128,7 @@ func (ms Migrations) NumberOfMigrationsTo(from int64, to int64) int {
 		}
 	}
 	if idxFrom == -1 {
-		panic(fmt.Sprintf("did not find from version %v", from))
+		return -1, ErrVersionNotFound{Version: from}
 	}
 	if idxTo == -1 {
 		idxTo = 0
@@ -132,9 +137,9 @@ func (ms Migrations) NumberOfMigrationsTo(from int64, to int64) int {
 		}
 	}
 	if down {
-		return idxFrom - idxTo + 1
+		return idxFrom - idxTo + 1, nil
 	}
-	return idxTo - idxFrom + 1
+	return idxTo - idxFrom + 1, nil
 
 }
 
@@ -173,33 +178,131 @@ func (ms Migrations) String() string {
 	return str
 }
 
-// AddMigration adds a migration.
+// AddMigration adds a migration, it will panic if the migration can not be added,
+// use AddMigrationE to get an error instead.
 func AddMigration(up func(*sql.Tx) error, down func(*sql.Tx) error) {
 	_, filename, _, _ := runtime.Caller(1)
 	AddNamedMigration(filename, up, down)
 }
 
+// AddMigration adds a migration, it will panic if the migration can not be added,
+// use AddMigrationE to get an error instead.
 func (p *Provider) AddMigration(up func(*sql.Tx) error, down func(*sql.Tx) error) {
 	_, filename, _, _ := runtime.Caller(1)
 	p.AddNamedMigration(filename, up, down)
 }
 
-// AddNamedMigration : Add a named migration.
+// AddMigrationE adds a migration, returning an error if the migration can not be added.
+func AddMigrationE(up func(*sql.Tx) error, down func(*sql.Tx) error) error {
+	_, filename, _, _ := runtime.Caller(1)
+	return defaultProvider.AddNamedMigrationE(filename, up, down)
+}
+
+// AddMigrationE adds a migration, returning an error if the migration can not be added.
+func (p *Provider) AddMigrationE(up func(*sql.Tx) error, down func(*sql.Tx) error) error {
+	_, filename, _, _ := runtime.Caller(1)
+	return p.AddNamedMigrationE(filename, up, down)
+}
+
+// AddNamedMigration : Add a named migration. It will panic if the migration can not be added,
+// use AddNamedMigrationE to get an error instead.
 func AddNamedMigration(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error) {
 	defaultProvider.AddNamedMigration(filename, up, down)
 	return
 }
 
-// AddNamedMigration : Add a named migration.
+// AddNamedMigration : Add a named migration. It will panic if the migration can not be added,
+// use AddNamedMigrationE to get an error instead.
 func (p *Provider) AddNamedMigration(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error) {
-	v, _ := NumericComponent(filename)
+	if err := p.AddNamedMigrationE(filename, up, down); err != nil {
+		panic(err.Error())
+	}
+}
+
+// AddNamedMigrationE : Add a named migration, returning an error if the migration can not be added.
+func AddNamedMigrationE(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error) error {
+	return defaultProvider.AddNamedMigrationE(filename, up, down)
+}
+
+// AddNamedMigrationE : Add a named migration, returning an error if the migration can not be added.
+// An ErrMigrationFilename is returned if the filename has no version, and an ErrDuplicateVersion if
+// a migration with the same version has already been added.
+func (p *Provider) AddNamedMigrationE(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error) error {
+	v, err := NumericComponent(filename)
+	if err != nil {
+		return ErrMigrationFilename{Filename: filename, ErrUnwrap: ErrUnwrap{err}}
+	}
 	migration := &Migration{Version: v, Next: -1, Previous: -1, Registered: true, UpFn: up, DownFn: down, Source: filename}
 
 	if existing, ok := p.registeredGoMigrations[v]; ok {
-		panic(fmt.Sprintf("failed to add migration %q: version conflicts with %q", filename, existing.Source))
+		return ErrDuplicateVersion{Version: v, Sources: []string{existing.Source, filename}}
 	}
 
 	p.registeredGoMigrations[v] = migration
+	return nil
+}
+
+// MigrationOption configures a Go migration added with AddMigrationWithOptions
+type MigrationOption func(m *Migration)
+
+// MigrationTags sets the tags of the migration, see WithTags.
+func MigrationTags(tags ...string) MigrationOption {
+	return func(m *Migration) { m.Tags = append(m.Tags, tags...) }
+}
+
+// MigrationRequires sets the versions the migration depends on, as the Requires annotation does for a SQL
+// migration. Once any migration declares its dependencies the migrations are applied as a dependency graph.
+func MigrationRequires(versions ...int64) MigrationOption {
+	return func(m *Migration) { m.Requires = append(m.Requires, versions...) }
+}
+
+// AddMigrationWithOptions adds a migration configured with the options. It will panic if the migration
+// can not be added, use AddMigrationWithOptionsE to get an error instead.
+func AddMigrationWithOptions(up func(*sql.Tx) error, down func(*sql.Tx) error, opts ...MigrationOption) {
+	_, filename, _, _ := runtime.Caller(1)
+	defaultProvider.AddNamedMigrationWithOptions(filename, up, down, opts...)
+}
+
+// AddMigrationWithOptions adds a migration configured with the options. It will panic if the migration
+// can not be added, use AddMigrationWithOptionsE to get an error instead.
+func (p *Provider) AddMigrationWithOptions(up func(*sql.Tx) error, down func(*sql.Tx) error, opts ...MigrationOption) {
+	_, filename, _, _ := runtime.Caller(1)
+	p.AddNamedMigrationWithOptions(filename, up, down, opts...)
+}
+
+// AddMigrationWithOptionsE adds a migration configured with the options, returning an error if the
+// migration can not be added.
+func AddMigrationWithOptionsE(up func(*sql.Tx) error, down func(*sql.Tx) error, opts ...MigrationOption) error {
+	_, filename, _, _ := runtime.Caller(1)
+	return defaultProvider.AddNamedMigrationWithOptionsE(filename, up, down, opts...)
+}
+
+// AddMigrationWithOptionsE adds a migration configured with the options, returning an error if the
+// migration can not be added.
+func (p *Provider) AddMigrationWithOptionsE(up func(*sql.Tx) error, down func(*sql.Tx) error, opts ...MigrationOption) error {
+	_, filename, _, _ := runtime.Caller(1)
+	return p.AddNamedMigrationWithOptionsE(filename, up, down, opts...)
+}
+
+// AddNamedMigrationWithOptions adds a named migration configured with the options. It will panic if the
+// migration can not be added, use AddNamedMigrationWithOptionsE to get an error instead.
+func (p *Provider) AddNamedMigrationWithOptions(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error, opts ...MigrationOption) {
+	if err := p.AddNamedMigrationWithOptionsE(filename, up, down, opts...); err != nil {
+		panic(err.Error())
+	}
+}
+
+// AddNamedMigrationWithOptionsE adds a named migration configured with the options, returning an error if
+// the migration can not be added, see AddNamedMigrationE.
+func (p *Provider) AddNamedMigrationWithOptionsE(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error, opts ...MigrationOption) error {
+	if err := p.AddNamedMigrationE(filename, up, down); err != nil {
+		return err
+	}
+	v, _ := NumericComponent(filename)
+	for _, opt := range opts {
+		opt(p.registeredGoMigrations[v])
+	}
+	return nil
 }
 
 func (p *Provider) collectMigrationsFS(fsys fs.FS, dirpath string, current, target int64) (Migrations, error) {
@@ -215,12 +318,23 @@ func (p *Provider) collectMigrationsFS(fsys fs.FS, dirpath string, current, targ
 		return nil, err
 	}
 	for _, file := range sqlMigrationFiles {
+		if isSQLCallback(file) || isRepeatable(file) {
+			continue
+		}
 		v, err := NumericComponent(file)
 		if err != nil {
 			return nil, fmt.Errorf("could not parse SQL migration file %q: %w", file, err)
 		}
 		if versionFilter(v, current, target) {
-			migration := &Migration{Version: v, Next: -1, Previous: -1, Source: file}
+			annotations, err := readAnnotations(fsys, file, tagsAnnotation, requiresAnnotation)
+			if err != nil {
+				return nil, fmt.Errorf("could not read annotations of SQL migration file %q: %w", file, err)
+			}
+			requires, err := parseRequires(annotations[requiresAnnotation])
+			if err != nil {
+				return nil, fmt.Errorf("could not parse SQL migration file %q: %w", file, err)
+			}
+			migration := &Migration{Version: v, Next: -1, Previous: -1, Source: file, Tags: parseTags(annotations[tagsAnnotation]), Requires: requires}
 			migrations = append(migrations, migration)
 		}
 	}
@@ -233,7 +347,9 @@ func (p *Provider) collectMigrationsFS(fsys fs.FS, dirpath string, current, targ
 			return nil, fmt.Errorf("could not parse go migration file %q: %w", migration.Source, err)
 		}
 		if versionFilter(v, current, target) {
-			migrations = append(migrations, migration)
+			// a copy, as the migrations are connected to each other, and commands may run concurrently
+			m := *migration
+			migrations = append(migrations, &m)
 		}
 	}
 
@@ -259,9 +375,7 @@ func (p *Provider) collectMigrationsFS(fsys fs.FS, dirpath string, current, targ
 		}
 	}
 
-	migrations = sortAndConnectMigrations(migrations)
-
-	return migrations, nil
+	return sortAndConnectMigrations(migrations)
 }
 
 // CollectMigrations returns all the valid looking migration scripts in the
@@ -274,8 +388,18 @@ func (p *Provider) CollectMigrations(dirPath string, current, target int64) (Mig
 	return p.collectMigrationsFS(p.baseFS, dirPath, current, target)
 }
 
-func sortAndConnectMigrations(migrations Migrations) Migrations {
-	sort.Sort(migrations)
+// sortAndConnectMigrations sorts the migrations by version, and populates next and previous for each migration.
+// An ErrDuplicateVersion is returned if two migrations have the same version.
+func sortAndConnectMigrations(migrations Migrations) (Migrations, error) {
+	sort.Stable(migrations)
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i-1].Version == migrations[i].Version {
+			return nil, ErrDuplicateVersion{
+				Version: migrations[i].Version,
+				Sources: []string{migrations[i-1].Source, migrations[i].Source},
+			}
+		}
+	}
 
 	// now that we're sorted in the appropriate direction,
 	// populate next and previous for each migration
@@ -288,7 +412,7 @@ func sortAndConnectMigrations(migrations Migrations) Migrations {
 		migrations[i].Previous = prev
 	}
 
-	return migrations
+	return migrations, nil
 }
 
 func versionFilter(v, current, target int64) bool {
@@ -313,9 +437,18 @@ func EnsureDBVersion(db *sql.DB) (int64, error) {
 // EnsureDBVersion retrieves the current version for this DB.
 // Create and initialize the DB version table if it doesn't exist.
 func (p *Provider) EnsureDBVersion(db *sql.DB) (int64, error) {
+	return p.dbVersion(db, false)
+}
+
+// dbVersion retrieves the current version for this DB. If readOnly is set the version table is not created
+// if it doesn't exist, an ErrNotInitialized is returned instead.
+func (p *Provider) dbVersion(db *sql.DB, readOnly bool) (int64, error) {
 	dialect := p.dialect
-	rows, err := dialect.dbVersionQuery(db)
+	rows, err := dialect.DBVersionQuery(db)
 	if err != nil {
+		if readOnly {
+			return 0, ErrNotInitialized{Table: p.tableName, ErrUnwrap: ErrUnwrap{err}}
+		}
 		return 0, createVersionTable(dialect, db)
 	}
 	defer rows.Close()
@@ -364,19 +497,32 @@ func (p *Provider) EnsureDBVersion(db *sql.DB) (int64, error) {
 // Create the db version table
 // and insert the initial 0 value into it
 func createVersionTable(d SQLDialect, db *sql.DB) error {
+	if schemaSQL := d.CreateSchemaSQL(); schemaSQL != "" {
+		if _, err := db.Exec(schemaSQL); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+	version := 0
+	applied := true
+	if !CapabilitiesOf(d).Transactions {
+		if _, err := db.Exec(d.CreateVersionTableSQL()); err != nil {
+			return err
+		}
+		_, err := db.Exec(d.InsertVersionSQL(), version, applied)
+		return err
+	}
+
 	txn, err := db.Begin()
 	if err != nil {
 		return err
 	}
 
-	if _, err := txn.Exec(d.createVersionTableSQL()); err != nil {
+	if _, err := txn.Exec(d.CreateVersionTableSQL()); err != nil {
 		txn.Rollback()
 		return err
 	}
 
-	version := 0
-	applied := true
-	if _, err := txn.Exec(d.insertVersionSQL(), version, applied); err != nil {
+	if _, err := txn.Exec(d.InsertVersionSQL(), version, applied); err != nil {
 		txn.Rollback()
 		return err
 	}
@@ -1,6 +1,7 @@
 package goose
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -15,7 +16,10 @@ func TestMigrationSort(t *testing.T) {
 	ms = append(ms, newMigration(20129000, "test"))
 	ms = append(ms, newMigration(20127000, "test"))
 
-	ms = sortAndConnectMigrations(ms)
+	ms, err := sortAndConnectMigrations(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	sorted := []int64{20120000, 20127000, 20128000, 20129000}
 
@@ -56,3 +60,56 @@ func validateMigrationSort(t *testing.T, ms Migrations, sorted []int64) {
 
 	t.Log(ms)
 }
+
+func TestMigrationErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("duplicate versions", func(t *testing.T) {
+		ms := Migrations{newMigration(1, "00001_a.sql"), newMigration(2, "00002_b.sql"), newMigration(1, "00001_a.go")}
+		_, err := sortAndConnectMigrations(ms)
+		var dupErr ErrDuplicateVersion
+		if !errors.As(err, &dupErr) {
+			t.Fatalf("error, got %v expected ErrDuplicateVersion", err)
+		}
+		if dupErr.Version != 1 {
+			t.Errorf("version, got %v expected 1", dupErr.Version)
+		}
+	})
+	t.Run("number of migrations to missing version", func(t *testing.T) {
+		ms := Migrations{newMigration(1, "00001_a.sql"), newMigration(2, "00002_b.sql")}
+		_, err := ms.NumberOfMigrationsToE(3, 1)
+		var notFound ErrVersionNotFound
+		if !errors.As(err, &notFound) {
+			t.Fatalf("error, got %v expected ErrVersionNotFound", err)
+		}
+		defer func() {
+			if recover() == nil {
+				t.Errorf("NumberOfMigrationsTo of a missing version, expected a panic")
+			}
+		}()
+		ms.NumberOfMigrationsTo(3, 1)
+	})
+	t.Run("add named migration", func(t *testing.T) {
+		p := NewProvider()
+		if err := p.AddNamedMigrationE("00001_a.go", nil, nil); err != nil {
+			t.Fatalf("error, got %v expected nil", err)
+		}
+		err := p.AddNamedMigrationE("00001_b.go", nil, nil)
+		var dupErr ErrDuplicateVersion
+		if !errors.As(err, &dupErr) {
+			t.Errorf("error, got %v expected ErrDuplicateVersion", err)
+		}
+		err = p.AddNamedMigrationE("a.go", nil, nil)
+		var nameErr ErrMigrationFilename
+		if !errors.As(err, &nameErr) {
+			t.Errorf("error, got %v expected ErrMigrationFilename", err)
+		}
+	})
+	t.Run("unknown dialect", func(t *testing.T) {
+		_, err := NewProviderE(Dialect("unknown"))
+		var dialectErr ErrUnknownDialect
+		if !errors.As(err, &dialectErr) {
+			t.Errorf("error, got %v expected ErrUnknownDialect", err)
+		}
+	})
+}
@@ -21,6 +21,22 @@ type MigrationRecord struct {
 	IsApplied bool // was this a result of up() or down()
 }
 
+// Direction is the direction a migration is run in
+type Direction string
+
+const (
+	DirectionUp   Direction = "up"
+	DirectionDown Direction = "down"
+)
+
+// directionOf returns the Direction for the direction flag used through out goose, true being up.
+func directionOf(direction bool) Direction {
+	if direction {
+		return DirectionUp
+	}
+	return DirectionDown
+}
+
 // Migration struct.
 type Migration struct {
 	Version      int64
@@ -31,6 +47,13 @@ type Migration struct {
 	UpFn         func(*sql.Tx) error // Up go migration function
 	DownFn       func(*sql.Tx) error // Down go migration function
 	noVersioning bool
+	// repeatable migrations have no version, their checksum is recorded instead, see Repeatable
+	repeatable bool
+	checksum   string
+	// Tags are the tags of the migration, see WithTags
+	Tags []string
+	// Requires are the versions the migration depends on, it is applied after them, see MigrationRequires
+	Requires []int64
 }
 
 func (m *Migration) String() string {
@@ -44,7 +67,7 @@ func (m *Migration) Up(db *sql.DB) error {
 }
 
 func (m *Migration) UpWithProvider(p *Provider, db *sql.DB) error {
-	return m.run(p, db, true)
+	return m.run(p, db, true, nil)
 }
 
 // Down runs a down migration.
@@ -54,7 +77,7 @@ func (m *Migration) Down(db *sql.DB) error {
 }
 
 func (m *Migration) DownWithProvider(p *Provider, db *sql.DB) error {
-	return m.run(p, db, false)
+	return m.run(p, db, false, nil)
 }
 
 // IsTimestamp returns weather the migration version can be considered to be a timestamp version, v.s. a Seq version. This means that the user can never have more than
@@ -68,31 +91,6 @@ func (m *Migration) IsTimestamp() bool {
 
 }
 
-// runSql will parse out the sql statements from the given io.Reader for the direction, and apply them to the
-// provided db connection
-func (m *Migration) runSql(f io.Reader, p *Provider, db *sql.DB, direction bool) error {
-	statements, useTx, err := parseSQLMigration(p, f, direction)
-	if err != nil {
-		return ErrMigrationSQLParse{
-			Filename:  filepath.Base(m.Source),
-			ErrUnwrap: ErrUnwrap{err},
-			Up:        direction,
-		}
-	}
-
-	if err := runSQLMigration(p, db, statements, useTx, m.Version, direction, m.noVersioning); err != nil {
-		return fmt.Errorf("ERROR %v: failed to run SQL migration: %w", filepath.Base(m.Source), err)
-	}
-
-	if len(statements) > 0 {
-		p.log.Println("OK   ", filepath.Base(m.Source))
-		return nil
-	}
-
-	p.log.Println("EMPTY", filepath.Base(m.Source))
-	return nil
-}
-
 func getExtension(s string) string {
 	b := []byte(filepath.Base(s)) // shadow
 	i := bytes.LastIndexByte(b, '.')
@@ -106,14 +104,23 @@ func getExtension(s string) string {
 	return string(b[i:])
 }
 
-func (m *Migration) parseAndRunSQLMigration(p *Provider, db *sql.DB, f io.Reader, direction bool) error {
-	statements, useTx, err := parseSQLMigration(p, f, direction)
+func (m *Migration) parseAndRunSQLMigration(p *Provider, db *sql.DB, f io.Reader, direction bool, option *runState) error {
+	statements, lines, useTx, err := parseSQLMigrationLines(p, f, direction)
 	if err != nil {
-		return fmt.Errorf("ERROR %v: failed to parse SQL migration file: %w", filepath.Base(m.Source), err)
+		merr := m.migrationError(direction, ErrMigrationSQLParse{
+			Filename:  filepath.Base(m.Source),
+			ErrUnwrap: ErrUnwrap{err},
+			Up:        direction,
+		})
+		var lineErr ErrSQLParseLine
+		if errors.As(err, &lineErr) {
+			merr.Line = lineErr.Line
+		}
+		return merr
 	}
 
-	if err := runSQLMigration(p, db, statements, useTx, m.Version, direction, m.noVersioning); err != nil {
-		return fmt.Errorf("ERROR %v: failed to run SQL migration: %w", filepath.Base(m.Source), err)
+	if err := runSQLMigration(p, db, m, statements, lines, useTx, direction, option); err != nil {
+		return err
 	}
 
 	if len(statements) > 0 {
@@ -124,6 +131,17 @@ func (m *Migration) parseAndRunSQLMigration(p *Provider, db *sql.DB, f io.Reader
 	return nil
 }
 
+// migrationError returns a MigrationError for a failure that did not happen in one of the migration's statements
+func (m *Migration) migrationError(direction bool, err error) MigrationError {
+	return MigrationError{
+		Version:        m.Version,
+		Source:         m.Source,
+		Direction:      directionOf(direction),
+		StatementIndex: -1,
+		ErrUnwrap:      ErrUnwrap{err},
+	}
+}
+
 func parseExecuteTplSql(filesys fs.FS, source, packageName string) (*bytes.Buffer, error) {
 	type tplValue struct {
 		Filename    string
@@ -145,36 +163,46 @@ func parseExecuteTplSql(filesys fs.FS, source, packageName string) (*bytes.Buffe
 
 }
 
-func (m *Migration) run(p *Provider, db *sql.DB, direction bool) error {
+// run runs the migration in the given direction, option may be nil.
+func (m *Migration) run(p *Provider, db *sql.DB, direction bool, option *runState) error {
 	if p == nil {
 		p = defaultProvider
 	}
 
 	switch ext := getExtension(m.Source); ext {
 	default:
-		return ErrUnknownExtension{Extension: ext}
+		return m.migrationError(direction, ErrUnknownExtension{Extension: ext})
 	case ".sql":
 		f, err := p.baseFS.Open(m.Source)
 		if err != nil {
-			return fmt.Errorf("ERROR %v: failed to open SQL migration file: %w", filepath.Base(m.Source), err)
+			return m.migrationError(direction, fmt.Errorf("failed to open SQL migration file: %w", err))
 		}
 		defer f.Close()
-		return m.parseAndRunSQLMigration(p, db, f, direction)
+		return m.parseAndRunSQLMigration(p, db, f, direction, option)
 
 	case ".tpl.sql":
 		buff, err := parseExecuteTplSql(p.baseFS, m.Source, p.packageName)
 		if err != nil {
-			return err
+			return m.migrationError(direction, err)
 		}
-		return m.parseAndRunSQLMigration(p, db, buff, direction)
+		return m.parseAndRunSQLMigration(p, db, buff, direction, option)
 
 	case ".go":
 		if !m.Registered {
-			return fmt.Errorf("ERROR %v: failed to run Go migration: Go functions must be registered and built into a custom binary (see https://github.com/gdey/goose/tree/master/examples/go-migrations)", m.Source)
+			return m.migrationError(direction, fmt.Errorf("failed to run Go migration: Go functions must be registered and built into a custom binary (see https://github.com/gdey/goose/tree/master/examples/go-migrations)"))
 		}
 		tx, err := db.Begin()
 		if err != nil {
-			return fmt.Errorf("ERROR failed to begin transaction: %w", err)
+			return m.migrationError(direction, fmt.Errorf("failed to begin transaction: %w", err))
+		}
+		// Go migrations are always given a transaction, which means nothing if the database does not
+		// support them
+		inTx := p.Capabilities().Transactions
+		rollback := func(err error) error {
+			merr := m.migrationError(direction, err)
+			merr.InTransaction = inTx
+			merr.RolledBack = tx.Rollback() == nil && inTx
+			return merr
 		}
 
 		fn := m.UpFn
@@ -182,29 +210,26 @@ func (m *Migration) run(p *Provider, db *sql.DB, direction bool) error {
 			fn = m.DownFn
 		}
 
+		if err := p.runEachHooks(hookBeforeEach, m, direction, db, tx, option); err != nil {
+			return rollback(err)
+		}
 		if fn != nil {
 			// Run Go migration function.
 			if err := fn(tx); err != nil {
-				tx.Rollback()
-				return fmt.Errorf("ERROR %v: failed to run Go migration function %T: %w", filepath.Base(m.Source), fn, err)
+				return rollback(fmt.Errorf("failed to run Go migration function %T: %w", fn, err))
 			}
 		}
-		if !m.noVersioning {
-			if direction {
-				if _, err := tx.Exec(p.dialect.insertVersionSQL(), m.Version, direction); err != nil {
-					tx.Rollback()
-					return fmt.Errorf("ERROR failed to execute transaction: %w", err)
-				}
-			} else {
-				if _, err := tx.Exec(p.dialect.deleteVersionSQL(), m.Version); err != nil {
-					tx.Rollback()
-					return fmt.Errorf("ERROR failed to execute transaction: %w", err)
-				}
-			}
+		if err := p.recordMigration(tx.Exec, m, direction, option); err != nil {
+			return rollback(err)
+		}
+		if err := p.runEachHooks(hookAfterEach, m, direction, db, tx, option); err != nil {
+			return rollback(err)
 		}
 
 		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("ERROR failed to commit transaction: %w", err)
+			merr := m.migrationError(direction, fmt.Errorf("failed to commit transaction: %w", err))
+			merr.InTransaction = inTx
+			return merr
 		}
 
 		if fn != nil {
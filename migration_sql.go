@@ -3,10 +3,26 @@ package goose
 import (
 	"database/sql"
 	"fmt"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// maxEventStatementLength is the maximum length of the statement text sent in statement events
+const maxEventStatementLength = 200
+
+// truncateStatement shortens the statement to maxEventStatementLength characters
+func truncateStatement(s string) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= maxEventStatementLength {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxEventStatementLength-3]) + "..."
+}
+
 // Run a migration specified in raw SQL.
 //
 // Sections of the script can be annotated with a special comment,
@@ -15,97 +31,202 @@ import (
 //
 // All statements following an Up or Down directive are grouped together
 // until another direction directive is found.
-func runSQLMigration(p *Provider, db *sql.DB, statements []string, useTx bool, v int64, direction bool, noVersioning bool) error {
+//
+// lines are the lines in the migration's source each of the statements start on,
+// and is used to fill in the returned MigrationError. A StatementStartEvent and
+// StatementDoneEvent is sent for each statement, option may be nil.
+func runSQLMigration(p *Provider, db *sql.DB, m *Migration, statements []string, lines []int, useTx bool, direction bool, option *runState) error {
 	if p == nil {
 		p = defaultProvider
 	}
+	migrationErr := func(idx int, err error) MigrationError {
+		merr := MigrationError{
+			Version:        m.Version,
+			Source:         m.Source,
+			Direction:      directionOf(direction),
+			StatementIndex: idx,
+			InTransaction:  useTx,
+			ErrUnwrap:      ErrUnwrap{err},
+		}
+		if idx >= 0 && idx < len(statements) {
+			merr.Statement = clearStatement(statements[idx])
+		}
+		if idx >= 0 && idx < len(lines) {
+			merr.Line = lines[idx]
+		}
+		return merr
+	}
+	caps := p.Capabilities()
+	if useTx && !caps.Transactions {
+		p.verboseInfo("Dialect does not support transactions, not using a transaction")
+		useTx = false
+	}
 	if useTx {
 		// TRANSACTION.
 
+		// the database commits the transaction on each DDL statement, which can then not be rolled back
+		implicitCommit := !caps.TransactionalDDL && firstDDL(statements) >= 0
+		if implicitCommit {
+			p.log.Printf("goose: WARNING %s: DDL is committed implicitly by the database and is not rolled back if the migration fails, consider annotating it with NO TRANSACTION\n", filepath.Base(m.Source))
+		}
+		committed := false
+
 		p.verboseInfo("Begin transaction")
 
 		tx, err := db.Begin()
 		if err != nil {
-			return fmt.Errorf("failed to begin transaction: %w", err)
+			merr := migrationErr(-1, fmt.Errorf("failed to begin transaction: %w", err))
+			merr.InTransaction = false
+			return merr
+		}
+		rollback := func(merr MigrationError) MigrationError {
+			p.verboseInfo("Rollback transaction")
+			merr.RolledBack = tx.Rollback() == nil && !committed
+			merr.ImplicitCommit = committed
+			return merr
+		}
+
+		if err := p.runEachHooks(hookBeforeEach, m, direction, db, tx, option); err != nil {
+			return rollback(migrationErr(-1, err))
 		}
 
-		for _, query := range statements {
+		for i, query := range statements {
+			// the statements before the DDL statement are committed with it, even if it fails
+			committed = committed || (implicitCommit && isDDL(query))
 			p.verboseInfo("Executing statement: %s\n", clearStatement(query))
-			if err = p.execQuery(tx.Exec, query); err != nil {
-				p.verboseInfo("Rollback transaction")
-				tx.Rollback()
-				return fmt.Errorf("failed to execute SQL query %q: %w", clearStatement(query), err)
+			if err = p.execStatement(tx.Exec, m, i, query, option); err != nil {
+				return rollback(migrationErr(i, fmt.Errorf("failed to execute SQL query: %w", err)))
 			}
 		}
 
-		if !noVersioning {
-			if direction {
-				if err := p.execQuery(tx.Exec, p.dialect.insertVersionSQL(), v, direction); err != nil {
-					p.verboseInfo("Rollback transaction")
-					tx.Rollback()
-					return fmt.Errorf("failed to insert new goose version: %w", err)
-				}
-			} else {
-				if err := p.execQuery(tx.Exec, p.dialect.deleteVersionSQL(), v); err != nil {
-					p.verboseInfo("Rollback transaction")
-					tx.Rollback()
-					return fmt.Errorf("failed to delete goose version: %w", err)
-				}
-			}
+		if err := p.recordMigration(tx.Exec, m, direction, option); err != nil {
+			return rollback(migrationErr(-1, err))
+		}
+
+		if err := p.runEachHooks(hookAfterEach, m, direction, db, tx, option); err != nil {
+			return rollback(migrationErr(-1, err))
 		}
 
 		p.verboseInfo("Commit transaction")
 		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit transaction: %w", err)
+			return migrationErr(-1, fmt.Errorf("failed to commit transaction: %w", err))
 		}
 
 		return nil
 	}
 
 	// NO TRANSACTION.
-	for _, query := range statements {
+	if err := p.runEachHooks(hookBeforeEach, m, direction, db, nil, option); err != nil {
+		return migrationErr(-1, err)
+	}
+	for i, query := range statements {
 		p.verboseInfo("Executing statement: %s", clearStatement(query))
-		if err := p.execQuery(db.Exec, query); err != nil {
-			return fmt.Errorf("failed to execute SQL query %q: %w", clearStatement(query), err)
+		if err := p.execStatement(db.Exec, m, i, query, option); err != nil {
+			return migrationErr(i, fmt.Errorf("failed to execute SQL query: %w", err))
 		}
 	}
-	if !noVersioning {
-		if direction {
-			if err := p.execQuery(db.Exec, p.dialect.insertVersionSQL(), v, direction); err != nil {
-				return fmt.Errorf("failed to insert new goose version: %w", err)
-			}
+	if err := p.recordMigration(db.Exec, m, direction, option); err != nil {
+		return migrationErr(-1, err)
+	}
+	if err := p.runEachHooks(hookAfterEach, m, direction, db, nil, option); err != nil {
+		return migrationErr(-1, err)
+	}
+
+	return nil
+}
+
+// recordMigration records the migration was applied, or rolled back, in the version table and the history,
+// the version row with the tags active when it was applied if the command records them. For a repeatable migration its checksum is
+// recorded instead. option may be nil.
+func (p *Provider) recordMigration(fn func(string, ...interface{}) (sql.Result, error), m *Migration, direction bool, option *runState) error {
+	switch {
+	case m.noVersioning:
+		return nil
+	case m.repeatable:
+		if _, err := p.execQuery(fn, p.dialect.DeleteRepeatableSQL(), filepath.Base(m.Source)); err != nil {
+			return fmt.Errorf("failed to delete goose repeatable checksum: %w", err)
+		}
+		if _, err := p.execQuery(fn, p.dialect.InsertRepeatableSQL(), filepath.Base(m.Source), m.checksum); err != nil {
+			return fmt.Errorf("failed to insert goose repeatable checksum: %w", err)
+		}
+	case direction:
+		var err error
+		if option != nil && option.recordTags {
+			_, err = p.execQuery(fn, p.dialect.InsertVersionTagsSQL(), m.Version, direction, option.activeTags())
 		} else {
-			if err := p.execQuery(db.Exec, p.dialect.deleteVersionSQL(), v); err != nil {
-				return fmt.Errorf("failed to delete goose version: %w", err)
-			}
+			_, err = p.execQuery(fn, p.dialect.InsertVersionSQL(), m.Version, direction)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert new goose version: %w", err)
+		}
+		if err := p.recordHistory(fn, m, direction, option); err != nil {
+			return err
+		}
+	default:
+		if _, err := p.execQuery(fn, p.dialect.DeleteVersionSQL(), m.Version); err != nil {
+			return fmt.Errorf("failed to delete goose version: %w", err)
+		}
+		if err := p.recordHistory(fn, m, direction, option); err != nil {
+			return err
 		}
 	}
-
 	return nil
 }
 
-func (p *Provider) execQuery(fn func(string, ...interface{}) (sql.Result, error), query string, args ...interface{}) error {
+// execStatement executes the statement of the migration, sending a StatementStartEvent before, and a
+// StatementDoneEvent after the statement has been executed.
+func (p *Provider) execStatement(fn func(string, ...interface{}) (sql.Result, error), m *Migration, idx int, query string, option *runState) error {
+	start := StatementStartEvent{
+		Version:        m.Version,
+		StatementIndex: idx,
+		Statement:      truncateStatement(clearStatement(query)),
+		StartAt:        time.Now(),
+	}
+	option.send(start)
+	result, err := p.execQuery(fn, query)
+	done := StatementDoneEvent{
+		Version:        start.Version,
+		StatementIndex: idx,
+		Statement:      start.Statement,
+		StartAt:        start.StartAt,
+		Duration:       time.Since(start.StartAt),
+		RowsAffected:   -1,
+		Err:            err,
+	}
+	if err == nil && result != nil {
+		if n, rerr := result.RowsAffected(); rerr == nil {
+			done.RowsAffected = n
+		}
+	}
+	option.send(done)
+	return err
+}
+
+func (p *Provider) execQuery(fn func(string, ...interface{}) (sql.Result, error), query string, args ...interface{}) (sql.Result, error) {
 	if p == nil {
 		p = defaultProvider
 	}
 	if !p.verbose {
-		_, err := fn(query, args...)
-		return err
+		return fn(query, args...)
 	}
 
-	ch := make(chan error)
+	type execResult struct {
+		result sql.Result
+		err    error
+	}
+	ch := make(chan execResult)
 
 	go func() {
-		_, err := fn(query, args...)
-		ch <- err
+		result, err := fn(query, args...)
+		ch <- execResult{result: result, err: err}
 	}()
 
 	t := time.Now()
 
 	for {
 		select {
-		case err := <-ch:
-			return err
+		case res := <-ch:
+			return res.result, res.err
 		case <-time.Tick(time.Minute):
 			p.verboseInfo("Executing statement still in progress for %v", time.Since(t).Round(time.Second))
 		}
@@ -1,6 +1,10 @@
 package goose
 
-import "testing"
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
 
 func Test_getExtension(t *testing.T) {
 
@@ -27,3 +31,283 @@ func Test_getExtension(t *testing.T) {
 	}
 
 }
+
+func TestMigrationError(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte(`-- +goose Up
+CREATE TABLE a (id INTEGER);
+
+INSERT INTO b (id) VALUES (1);
+
+-- +goose Down
+DROP TABLE a;
+`)},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+	err := p.Up(db, "migrations", WithNoOutput())
+	var merr MigrationError
+	if !errors.As(err, &merr) {
+		t.Fatalf("error, got %v expected MigrationError", err)
+	}
+	expected := MigrationError{
+		Version:        1,
+		Source:         "migrations/00001_a.sql",
+		Direction:      DirectionUp,
+		StatementIndex: 1,
+		Statement:      "INSERT INTO b (id) VALUES (1);\n",
+		Line:           4,
+		InTransaction:  true,
+		RolledBack:     true,
+	}
+	merr.ErrUnwrap = ErrUnwrap{}
+	if merr != expected {
+		t.Errorf("migration error, got %+v expected %+v", merr, expected)
+	}
+	if _, err := db.Exec("SELECT id FROM a"); err == nil {
+		t.Errorf("expected table a to have been rolled back")
+	}
+}
+
+func TestStatementEvents(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte(`-- +goose Up
+CREATE TABLE a (id INTEGER);
+INSERT INTO a (id) VALUES (1), (2);
+
+-- +goose Down
+DROP TABLE a;
+`)},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+	events := make(chan Eventer)
+	var got []Eventer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range events {
+			switch e.(type) {
+			case StatementStartEvent, StatementDoneEvent:
+				got = append(got, e)
+			}
+		}
+	}()
+	if err := p.Up(db, "migrations", WithNoOutput(), WithEvents(events, false)); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	expected := []Eventer{
+		StatementStartEvent{Version: 1, StatementIndex: 0, Statement: "CREATE TABLE a (id INTEGER);"},
+		StatementDoneEvent{Version: 1, StatementIndex: 0, Statement: "CREATE TABLE a (id INTEGER);", RowsAffected: 0},
+		StatementStartEvent{Version: 1, StatementIndex: 1, Statement: "INSERT INTO a (id) VALUES (1), (2);"},
+		StatementDoneEvent{Version: 1, StatementIndex: 1, Statement: "INSERT INTO a (id) VALUES (1), (2);", RowsAffected: 2},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("number of events, got %v expected %v", len(got), len(expected))
+	}
+	// the rows affected by DDL is up to the driver, sqlite reports the count of the last DML statement
+	if done, ok := got[1].(StatementDoneEvent); ok {
+		ddl := expected[1].(StatementDoneEvent)
+		ddl.RowsAffected = done.RowsAffected
+		expected[1] = ddl
+	}
+	for i := range expected {
+		if !AreEventsEqual(expected[i], got[i]) {
+			t.Errorf("event %d, got %+v expected %+v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestCommandEvents(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/00002_b.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+		"migrations/00003_c.sql": {Data: []byte("-- +goose Up\nCREATE TABLE c (id INTEGER);\n-- +goose Down\nDROP TABLE missing;\n")},
+	}
+	const (
+		a = "migrations/00001_a.sql"
+		b = "migrations/00002_b.sql"
+		c = "migrations/00003_c.sql"
+	)
+	p, db := newSQLiteProvider(t, fsys)
+
+	// run runs the command, returning the events it sent, without the statement events
+	run := func(command func(opts ...OptionsFunc) error) (got []Eventer, err error) {
+		events := make(chan Eventer)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for e := range events {
+				switch e.(type) {
+				case StatementStartEvent, StatementDoneEvent:
+				default:
+					got = append(got, e)
+				}
+			}
+		}()
+		err = command(WithNoOutput(), WithEvents(events, false))
+		<-done
+		return got, err
+	}
+	applied := func(e VersionApplyEvent) []Eventer {
+		before := e
+		e.Applied = true
+		return []Eventer{before, e}
+	}
+
+	type tcase struct {
+		name     string
+		command  func(opts ...OptionsFunc) error
+		err      bool
+		expected []Eventer
+	}
+	tests := []tcase{
+		{
+			name:    "up-to",
+			command: func(opts ...OptionsFunc) error { return p.UpTo(db, "migrations", 2, opts...) },
+			expected: append(append(append([]Eventer{
+				VersionCountEvent{Version: -1, TotalVersionsLeft: 2}},
+				applied(VersionApplyEvent{From: -1, To: 1, ToSource: a, Versioned: true})...),
+				applied(VersionApplyEvent{From: 1, FromSource: a, To: 2, ToSource: b, Versioned: true})...),
+				CommandSummaryEvent{Command: "up-to", Applied: 2, Version: 2},
+			),
+		},
+		{
+			name:    "redo",
+			command: func(opts ...OptionsFunc) error { return p.Redo(db, "migrations", opts...) },
+			expected: append(append(append([]Eventer{
+				VersionCountEvent{Version: 2, VersionSource: b, TotalVersionsLeft: 2}},
+				applied(VersionApplyEvent{From: 2, FromSource: b, To: 1, ToSource: a, Down: true, Versioned: true})...),
+				applied(VersionApplyEvent{From: 1, FromSource: a, To: 2, ToSource: b, Versioned: true})...),
+				CommandSummaryEvent{Command: "redo", Applied: 2, Version: 2},
+			),
+		},
+		{
+			name:    "down-to",
+			command: func(opts ...OptionsFunc) error { return p.DownTo(db, "migrations", 0, opts...) },
+			expected: append(append(append([]Eventer{
+				VersionCountEvent{Version: 2, VersionSource: b, TotalVersionsLeft: 2}},
+				applied(VersionApplyEvent{From: 2, FromSource: b, To: 1, ToSource: a, Down: true, Versioned: true})...),
+				applied(VersionApplyEvent{From: 1, FromSource: a, To: 0, Down: true, Versioned: true})...),
+				CommandSummaryEvent{Command: "down-to", Down: true, Applied: 2, Version: 0},
+			),
+		},
+		{
+			name:    "up",
+			command: func(opts ...OptionsFunc) error { return p.Up(db, "migrations", opts...) },
+			expected: append(append(append(append([]Eventer{
+				VersionCountEvent{Version: -1, TotalVersionsLeft: 3}},
+				applied(VersionApplyEvent{From: -1, To: 1, ToSource: a, Versioned: true})...),
+				applied(VersionApplyEvent{From: 1, FromSource: a, To: 2, ToSource: b, Versioned: true})...),
+				applied(VersionApplyEvent{From: 2, FromSource: b, To: 3, ToSource: c, Versioned: true})...),
+				CommandSummaryEvent{Command: "up", Applied: 3, Version: 3},
+			),
+		},
+		{
+			name:    "down fails",
+			command: func(opts ...OptionsFunc) error { return p.Down(db, "migrations", opts...) },
+			err:     true,
+			expected: []Eventer{
+				VersionCountEvent{Version: 3, VersionSource: c, TotalVersionsLeft: 1},
+				VersionApplyEvent{From: 3, FromSource: c, To: 2, ToSource: b, Down: true, Versioned: true},
+				VersionFailedEvent{From: 3, FromSource: c, To: 2, ToSource: b, Down: true, Versioned: true, Err: errors.New("failed")},
+				CommandSummaryEvent{Command: "down", Down: true, Applied: 0, Version: -1, Err: errors.New("failed")},
+			},
+		},
+		{
+			name:    "reset fails",
+			command: func(opts ...OptionsFunc) error { return p.Reset(db, "migrations", opts...) },
+			err:     true,
+			expected: []Eventer{
+				VersionCountEvent{Version: 3, VersionSource: c, TotalVersionsLeft: 3},
+				VersionApplyEvent{From: 3, FromSource: c, To: 2, ToSource: b, Down: true, Versioned: true},
+				VersionFailedEvent{From: 3, FromSource: c, To: 2, ToSource: b, Down: true, Versioned: true, Err: errors.New("failed")},
+				CommandSummaryEvent{Command: "reset", Down: true, Applied: 0, Version: -1, Err: errors.New("failed")},
+			},
+		},
+	}
+	// the tests build on each other, so they can not be run in parallel
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := run(tc.command)
+			if (err != nil) != tc.err {
+				t.Fatalf("error, got %v expected error %v", err, tc.err)
+			}
+			if len(got) != len(tc.expected) {
+				t.Fatalf("number of events, got %v expected %v\n%+v", len(got), len(tc.expected), got)
+			}
+			for i := range tc.expected {
+				if !AreEventsEqual(tc.expected[i], got[i]) {
+					t.Errorf("event %d, got %+v expected %+v", i, got[i], tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCommandEventsNothingToDo(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+
+	tests := []struct {
+		name    string
+		command func(opts ...OptionsFunc) error
+		err     bool
+		summary CommandSummaryEvent
+	}{
+		{
+			name:    "reset with nothing applied",
+			command: func(opts ...OptionsFunc) error { return p.Reset(db, "migrations", opts...) },
+			summary: CommandSummaryEvent{Command: "reset", Down: true, Version: -1},
+		},
+		{
+			name:    "down with nothing applied",
+			command: func(opts ...OptionsFunc) error { return p.Down(db, "migrations", opts...) },
+			err:     true,
+			summary: CommandSummaryEvent{Command: "down", Down: true, Version: -1, Err: errors.New("failed")},
+		},
+		{
+			name: "down-to the current version",
+			command: func(opts ...OptionsFunc) error {
+				if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+					return err
+				}
+				return p.DownTo(db, "migrations", 1, opts...)
+			},
+			summary: CommandSummaryEvent{Command: "down-to", Down: true, Version: -1},
+		},
+	}
+	// the tests build on each other, so they can not be run in parallel
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			events := make(chan Eventer, 10)
+			err := tc.command(WithNoOutput(), WithEvents(events, false))
+			if (err != nil) != tc.err {
+				t.Fatalf("error, got %v expected error %v", err, tc.err)
+			}
+			var got []Eventer
+			for e := range events {
+				got = append(got, e)
+			}
+			expected := []Eventer{VersionCountEvent{Version: -1}, tc.summary}
+			if len(got) != len(expected) {
+				t.Fatalf("events, got %+v expected %+v", got, expected)
+			}
+			for i := range expected {
+				if !AreEventsEqual(expected[i], got[i]) {
+					t.Errorf("event %d, got %+v expected %+v", i, got[i], expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// orphanedVersions returns the versions applied to the database there is none of the migrations for, ordered by version
+func (p *Provider) orphanedVersions(db *sql.DB, migrations Migrations) ([]int64, error) {
+	applied, err := p.dbAppliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		found[m.Version] = true
+	}
+	var orphans []int64
+	for _, v := range applied {
+		if !found[v] {
+			orphans = append(orphans, v)
+		}
+	}
+	return orphans, nil
+}
+
+// Orphans returns the orphaned versions of the database, see Provider.Orphans
+func Orphans(db *sql.DB, dir string) ([]int64, error) {
+	return defaultProvider.Orphans(db, dir)
+}
+
+// Orphans returns the versions applied to the database that have no migration in dir, ordered by version.
+// They are usually applied from a branch that was later dropped, and can not be rolled back.
+func (p *Provider) Orphans(db *sql.DB, dir string) ([]int64, error) {
+	migrations, err := p.CollectMigrations(dir, minVersion, maxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	return p.orphanedVersions(db, migrations)
+}
+
+// VerifyDB checks the database against the migrations, see Provider.VerifyDB
+func VerifyDB(db *sql.DB, dir string) error {
+	return defaultProvider.VerifyDB(db, dir)
+}
+
+// VerifyDB checks the database against the migrations in dir, an ErrOrphanedVersions is returned if
+// versions applied to the database have no migration.
+func (p *Provider) VerifyDB(db *sql.DB, dir string) error {
+	orphans, err := p.Orphans(db, dir)
+	if err != nil {
+		return err
+	}
+	if len(orphans) > 0 {
+		return ErrOrphanedVersions{Versions: orphans}
+	}
+	return nil
+}
+
+// PruneOrphans removes the version rows of the orphaned versions, see Provider.PruneOrphans
+func PruneOrphans(db *sql.DB, dir string, opts ...OptionsFunc) ([]int64, error) {
+	return defaultProvider.PruneOrphans(db, dir, opts...)
+}
+
+// PruneOrphans removes the version rows of the versions applied to the database that have no migration in
+// dir, and returns the versions removed. Only the version rows are removed, whatever the migrations changed
+// stays as it is. With WithDryRun the versions are returned without removing them.
+func (p *Provider) PruneOrphans(db *sql.DB, dir string, opts ...OptionsFunc) ([]int64, error) {
+	option := p.applyOptions(opts)
+	orphans, err := p.Orphans(db, dir)
+	if err != nil || len(orphans) == 0 || option.dryRun {
+		return orphans, err
+	}
+	if err := option.checkReadOnly("prune-orphans"); err != nil {
+		return nil, err
+	}
+
+	exec := db.Exec
+	var tx *sql.Tx
+	if p.Capabilities().Transactions {
+		if tx, err = db.Begin(); err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		exec = tx.Exec
+	}
+	for _, v := range orphans {
+		p.verboseInfo("Removing orphaned version %d", v)
+		if _, err := p.execQuery(exec, p.dialect.DeleteVersionSQL(), v); err != nil {
+			if tx != nil {
+				_ = tx.Rollback()
+			}
+			return nil, fmt.Errorf("failed to delete goose version %d: %w", v, err)
+		}
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+	}
+	return orphans, nil
+}
@@ -0,0 +1,80 @@
+package goose
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOrphanedVersions(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n")},
+		"migrations/00003_posts.sql": {Data: []byte("-- +goose Up\nCREATE TABLE posts (id INTEGER);\n-- +goose Down\nDROP TABLE posts;\n")},
+	}
+	branch := fstest.MapFS{
+		"migrations/00002_branch.sql": {Data: []byte("-- +goose Up\nCREATE TABLE branch (id INTEGER);\n-- +goose Down\nDROP TABLE branch;\n")},
+		"migrations/00004_branch.sql": {Data: []byte("-- +goose Up\nCREATE TABLE branch_more (id INTEGER);\n-- +goose Down\nDROP TABLE branch_more;\n")},
+	}
+	for name, file := range fsys {
+		branch[name] = file
+	}
+	db := openSQLite(t)
+	if err := NewProvider(Filesystem(branch), Dialect(DialectSQLite3)).Up(db, "migrations", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProvider(Filesystem(fsys), Dialect(DialectSQLite3))
+
+	events := make(chan Eventer, 10)
+	if err := p.Status(db, "migrations", WithNoOutput(), WithEvents(events, false)); err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for e := range events {
+		if status, ok := e.(StatusEvent); ok {
+			statuses = append(statuses, status.Script())
+		}
+	}
+	if expected := []string{"00001_users.sql", "2 (orphaned, no migration)", "00003_posts.sql", "4 (orphaned, no migration)"}; !equalStrings(statuses, expected) {
+		t.Errorf("status, got %v expected %v", statuses, expected)
+	}
+
+	var oerr ErrOrphanedVersions
+	if err := p.VerifyDB(db, "migrations"); !errors.As(err, &oerr) || len(oerr.Versions) != 2 {
+		t.Errorf("verify db, got %v expected the orphaned versions 2 and 4", err)
+	}
+	if err := p.Down(db, "migrations", WithNoOutput()); !errors.As(err, &oerr) || oerr.Versions[0] != 4 {
+		t.Errorf("down, got %v expected the orphaned version 4", err)
+	}
+	if _, err := p.PruneOrphans(db, "migrations", WithReadOnly()); !errors.As(err, &ErrReadOnly{}) {
+		t.Errorf("read-only prune, got %v expected an ErrReadOnly", err)
+	}
+	if orphans, err := p.PruneOrphans(db, "migrations", WithDryRun()); err != nil || len(orphans) != 2 {
+		t.Errorf("dry-run prune, got %v, %v expected the orphaned versions 2 and 4", orphans, err)
+	}
+	if orphans, err := p.PruneOrphans(db, "migrations"); err != nil || len(orphans) != 2 || orphans[1] != 4 {
+		t.Errorf("prune, got %v, %v expected the orphaned versions 2 and 4", orphans, err)
+	}
+	if err := p.VerifyDB(db, "migrations"); err != nil {
+		t.Errorf("verify db, got %v expected nil", err)
+	}
+	if err := p.Down(db, "migrations", WithNoOutput()); err != nil {
+		t.Errorf("down, got %v expected nil", err)
+	}
+	if version, err := p.GetDBVersion(db); err != nil || version != 1 {
+		t.Errorf("version, got %d, %v expected 1", version, err)
+	}
+}
+
+func TestDownNothingApplied(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+	// version 0 is not an orphan, nothing is applied
+	err := p.Down(db, "migrations", WithNoOutput())
+	if err == nil || errors.As(err, &ErrOrphanedVersions{}) {
+		t.Errorf("down, got %v expected an error that is not ErrOrphanedVersions", err)
+	}
+}
@@ -1,6 +1,7 @@
 package goose
 
 import (
+	"errors"
 	"io/fs"
 	"path/filepath"
 	"runtime"
@@ -69,12 +70,17 @@ func Log(log Logger) func(p *Provider) {
 	}
 }
 
+// Dialect sets the dialect for the provider, an unknown dialect is an ErrUnknownDialect error
+// which is returned by NewProviderE
 func Dialect(dialect string) func(p *Provider) {
 	return func(p *Provider) {
 		dialect, err := SelectDialect(p.tableName, dialect)
 		if err != nil {
-			p.log.Fatal(err)
+			p.optionErrs = append(p.optionErrs, err)
+			return
 		}
+		dialect.SetSchema(p.tableSchema, p.createSchema)
+		setQuoteIdentifiers(dialect, p.quoteIdentifiers)
 		p.dialect = dialect
 	}
 }
@@ -99,8 +105,14 @@ func BaseDir(dir string) func(p *Provider) {
 
 func DialectObject(dialect SQLDialect) func(p *Provider) {
 	return func(p *Provider) {
+		if dialect == nil {
+			p.optionErrs = append(p.optionErrs, errors.New("dialect object must not be nil"))
+			return
+		}
 		p.dialect = dialect
 		p.dialect.SetTableName(p.tableName)
+		p.dialect.SetSchema(p.tableSchema, p.createSchema)
+		setQuoteIdentifiers(p.dialect, p.quoteIdentifiers)
 	}
 }
 
@@ -111,6 +123,26 @@ func Tablename(tablename string) func(p *Provider) {
 	}
 }
 
+// TableSchema sets the schema the version table is in, by default the default schema of the connection.
+// If create is set the schema is created, if it does not exist, before the version table is created.
+func TableSchema(schema string, create bool) func(p *Provider) {
+	return func(p *Provider) {
+		p.tableSchema = schema
+		p.createSchema = create
+		p.dialect.SetSchema(schema, create)
+	}
+}
+
+// QuoteIdentifiers quotes the names of the schema and the version table with the quote characters of
+// the dialect, so they are used as they are written. By default they are not quoted, and the database
+// folds them as it does any unquoted name, e.g. Postgres to lower case.
+func QuoteIdentifiers(quote bool) func(p *Provider) {
+	return func(p *Provider) {
+		p.quoteIdentifiers = quote
+		setQuoteIdentifiers(p.dialect, quote)
+	}
+}
+
 // ProviderPackage sets the packageName and providerVar used in templates
 func ProviderPackage(packageName, providerVar string) func(p *Provider) {
 	if packageName == "" {
@@ -134,6 +166,11 @@ type Provider struct {
 	dialect                SQLDialect
 	registeredGoMigrations map[int64]*Migration
 	tableName              string
+	// tableSchema is the schema of the version table, and createSchema if it is created, see TableSchema
+	tableSchema  string
+	createSchema bool
+	// quoteIdentifiers quotes the names of the schema and the version table, see QuoteIdentifiers
+	quoteIdentifiers bool
 	// seqVersionTemplate sets the template system will use this to format the digit of the sequence number
 	// by default it %05d, see seqVersionTemplate for actually default value.
 	seqVersionTemplate string
@@ -143,9 +180,31 @@ type Provider struct {
 	providerVarName string
 	// This is used for Create/Fix if the dir is not passed.
 	baseDir string
+	// optionErrs are the errors the options ran into while setting up the provider
+	optionErrs []error
+	// events are the subscriptions to the events of the commands run on the provider
+	events *eventBus
+	// the migration hooks, see HookFunc
+	beforeEach, afterEach, beforeAll, afterAll []HookFunc
+	// sqlCallbacksInTx runs the each migration SQL callbacks in the migration's transaction
+	sqlCallbacksInTx bool
+	// seedDir is the directory of the seed sets, see SeedDir
+	seedDir string
+}
+
+// NewProvider returns a new provider configured with the given options. If any of the options
+// fail the provider's logger Fatal method is called, use NewProviderE to get an error instead.
+func NewProvider(options ...providerOptions) *Provider {
+	p, err := NewProviderE(options...)
+	if err != nil {
+		p.log.Fatal(err)
+	}
+	return p
 }
 
-func NewProvider(options ...providerOptions) *Provider {
+// NewProviderE returns a new provider configured with the given options, or the errors any of
+// the options ran into.
+func NewProviderE(options ...providerOptions) (*Provider, error) {
 	p := &Provider{
 		timestampFormat:        defaultTimestampFormat,
 		timeFn:                 time.Now,
@@ -157,11 +216,14 @@ func NewProvider(options ...providerOptions) *Provider {
 		registeredGoMigrations: map[int64]*Migration{},
 		tableName:              defaultTableName,
 		packageName:            defaultProviderPackage,
+		events:                 &eventBus{},
 	}
 	for _, opt := range options {
 		opt(p)
 	}
-	return p
+	err := errors.Join(p.optionErrs...)
+	p.optionErrs = nil
+	return p, err
 }
 
 func (p *Provider) BaseDir(dir string) string {
@@ -0,0 +1,86 @@
+package goose
+
+import (
+	"bytes"
+	"errors"
+	std "log"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/00001_users.sql":    {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n")},
+		"migrations/R_users_view.sql":   {Data: []byte("-- +goose Up\nDROP VIEW IF EXISTS users_view;\nCREATE VIEW users_view AS SELECT id FROM users;\n")},
+		"migrations/00002_accounts.sql": {Data: []byte("-- +goose Up\nCREATE TABLE accounts (id INTEGER);\n-- +goose Down\nDROP TABLE accounts;\n")},
+	}
+	var buff bytes.Buffer
+	p, db := newSQLiteProvider(t, fsys, Log(std.New(&buff, "", 0)))
+	tables := func() []string {
+		t.Helper()
+		rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+
+	events := make(chan Eventer, 10)
+	if err := p.Status(db, "migrations", WithReadOnly(), WithEvents(events, false)); err != nil {
+		t.Fatal(err)
+	}
+	pending := 0
+	for e := range events {
+		if status, ok := e.(StatusEvent); ok && status.AppliedAt.IsZero() {
+			pending++
+		}
+	}
+	if pending != 3 {
+		t.Errorf("status, got %d pending migrations expected 3", pending)
+	}
+	if !strings.Contains(buff.String(), "not initialized") {
+		t.Errorf("status output, got %q expected not initialized", buff.String())
+	}
+	var nerr ErrNotInitialized
+	if _, _, err := p.GetVersions(db, "migrations", WithReadOnly()); !errors.As(err, &nerr) {
+		t.Errorf("versions, got %v expected an ErrNotInitialized", err)
+	}
+	if err := p.Version(db, "migrations", WithReadOnly()); err != nil {
+		t.Errorf("version, got %v expected nil", err)
+	}
+	var rerr ErrReadOnly
+	if err := p.Up(db, "migrations", WithReadOnly(), WithNoOutput()); !errors.As(err, &rerr) || rerr.Command != "up" {
+		t.Errorf("up, got %v expected an ErrReadOnly", err)
+	}
+	if got := tables(); len(got) != 0 {
+		t.Fatalf("tables, got %v expected none to be created", got)
+	}
+
+	if err := p.UpTo(db, "migrations", 1, WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	before := tables()
+	if _, dbVersion, err := p.GetVersions(db, "migrations", WithReadOnly()); err != nil || dbVersion != 1 {
+		t.Errorf("versions, got %d, %v expected 1", dbVersion, err)
+	}
+	if err := p.Status(db, "migrations", WithReadOnly(), WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.DownTo(db, "migrations", 0, WithReadOnly(), WithNoOutput()); !errors.As(err, &rerr) {
+		t.Errorf("down-to, got %v expected an ErrReadOnly", err)
+	}
+	if after := tables(); !equalStrings(before, after) {
+		t.Errorf("tables, got %v expected %v", after, before)
+	}
+}
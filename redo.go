@@ -10,12 +10,22 @@ func Redo(db *sql.DB, dir string, opts ...OptionsFunc) error {
 }
 
 // Redo rolls back the most recently applied migration, then runs it again.
-func (p *Provider) Redo(db *sql.DB, dir string, opts ...OptionsFunc) error {
-	option := applyOptions(opts)
+func (p *Provider) Redo(db *sql.DB, dir string, opts ...OptionsFunc) (err error) {
+	option := p.newRun(opts)
+	if option.shouldCloseEventsChannel() {
+		defer close(option.eventsChannel)
+	}
+	if err := option.checkReadOnly("redo"); err != nil {
+		return err
+	}
+	defer func() { err = p.finishCommand(db, option, "redo", false, err) }()
 	migrations, err := p.CollectMigrations(dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
+	if option.callbacks, err = p.collectSQLCallbacks(p.baseFS, dir); err != nil {
+		return err
+	}
 	var (
 		currentVersion int64
 	)
@@ -36,11 +46,31 @@ func (p *Provider) Redo(db *sql.DB, dir string, opts ...OptionsFunc) error {
 	}
 	current.noVersioning = option.noVersioning
 
-	if err := current.DownWithProvider(p, db); err != nil {
-		return err
+	var previous VersionApplyEvent
+	if m, err := migrations.Previous(current.Version); err == nil {
+		previous.To = m.Version
+		previous.ToSource = m.Source
 	}
-	if err := current.UpWithProvider(p, db); err != nil {
+	option.send(VersionCountEvent{
+		Version:           current.Version,
+		VersionSource:     current.Source,
+		TotalVersionsLeft: 2,
+	})
+	if err := p.applyMigration(db, current, VersionApplyEvent{
+		From:       current.Version,
+		FromSource: current.Source,
+		To:         previous.To,
+		ToSource:   previous.ToSource,
+		Down:       true,
+		Versioned:  !option.noVersioning,
+	}, option); err != nil {
 		return err
 	}
-	return nil
+	return p.applyMigration(db, current, VersionApplyEvent{
+		From:       previous.To,
+		FromSource: previous.ToSource,
+		To:         current.Version,
+		ToSource:   current.Source,
+		Versioned:  !option.noVersioning,
+	}, option)
 }
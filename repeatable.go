@@ -0,0 +1,202 @@
+package goose
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// repeatablePrefix is the prefix of the file name of repeatable migrations, e.g. R_refresh_views.sql
+const repeatablePrefix = "R_"
+
+// isRepeatable returns if the file is a repeatable migration
+func isRepeatable(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(base, repeatablePrefix) && strings.HasSuffix(base, ".sql")
+}
+
+// RepeatableApplyEvent is sent before, with Applied set to false, and after a repeatable migration is applied.
+// If the migration fails the second event has Err set instead.
+type RepeatableApplyEvent struct {
+	*Event `json:"-"`
+	Source string `json:"source"`
+	// Checksum is the checksum of the migration being applied, PreviousChecksum the checksum
+	// of the last time it was applied, empty if it never has been
+	Checksum         string    `json:"checksum"`
+	PreviousChecksum string    `json:"previous_checksum"`
+	ApplyAT          time.Time `json:"apply_at"`
+	Applied          bool      `json:"applied"`
+	// Err is the error the migration failed with
+	Err error `json:"-"`
+}
+
+func (e RepeatableApplyEvent) IsEqual(o Eventer) bool {
+	oe, ok := o.(RepeatableApplyEvent)
+	if !ok {
+		poe, ok := o.(*RepeatableApplyEvent)
+		if !ok || poe == nil {
+			return false
+		}
+		oe = *poe
+	}
+	return e.Source == oe.Source &&
+		e.Checksum == oe.Checksum &&
+		e.PreviousChecksum == oe.PreviousChecksum &&
+		e.Applied == oe.Applied &&
+		(e.Err == nil) == (oe.Err == nil)
+}
+
+var (
+	_ = Eventer((*RepeatableApplyEvent)(nil))
+	_ = Eventer(RepeatableApplyEvent{})
+)
+
+// repeatableRecord is the last time a repeatable migration was applied
+type repeatableRecord struct {
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// collectRepeatableFS returns the repeatable migrations in the directory, in name order, with their checksum.
+// The checksum of a .tpl.sql migration is of the executed template.
+func (p *Provider) collectRepeatableFS(fsys fs.FS, dir string) (Migrations, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, repeatablePrefix+"*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	migrations := make(Migrations, 0, len(files))
+	for _, file := range files {
+		var content []byte
+		if getExtension(file) == ".tpl.sql" {
+			buff, err := parseExecuteTplSql(fsys, file, p.packageName)
+			if err != nil {
+				return nil, err
+			}
+			content = buff.Bytes()
+		} else if content, err = fs.ReadFile(fsys, file); err != nil {
+			return nil, fmt.Errorf("failed to read repeatable migration %s: %w", file, err)
+		}
+		sum := sha256.Sum256(content)
+		migrations = append(migrations, &Migration{
+			Next:       -1,
+			Previous:   -1,
+			Source:     file,
+			repeatable: true,
+			checksum:   hex.EncodeToString(sum[:]),
+		})
+	}
+	return migrations, nil
+}
+
+// repeatableStatus returns the last time each repeatable migration was applied, by file name.
+// The repeatable migrations table is created if it does not exist, unless readOnly is set.
+func (p *Provider) repeatableStatus(db *sql.DB, readOnly bool) (map[string]repeatableRecord, error) {
+	query := func() (*sql.Rows, error) { return db.Query(p.dialect.RepeatableQuerySQL()) }
+	exists, err := p.tableExists(db, RepeatableTableSuffix, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query repeatable migrations: %w", err)
+	}
+	if !exists {
+		if readOnly {
+			return map[string]repeatableRecord{}, nil
+		}
+		if _, err := db.Exec(p.dialect.CreateRepeatableTableSQL()); err != nil {
+			return nil, fmt.Errorf("failed to create repeatable migrations table: %w", err)
+		}
+		return map[string]repeatableRecord{}, nil
+	}
+	rows, err := query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query repeatable migrations: %w", err)
+	}
+	defer rows.Close()
+
+	records := make(map[string]repeatableRecord)
+	for rows.Next() {
+		var (
+			name   string
+			record repeatableRecord
+		)
+		if err := rows.Scan(&name, &record.Checksum, &record.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		records[name] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get next row: %w", err)
+	}
+	return records, nil
+}
+
+// clearRepeatable deletes the checksums of the repeatable migrations, once all the migrations are rolled back,
+// so the next up applies them again. Nothing is created if the repeatable migrations table does not exist.
+func (p *Provider) clearRepeatable(db *sql.DB) error {
+	records, err := p.repeatableStatus(db, true)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(records))
+	for name := range records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := p.execQuery(db.Exec, p.dialect.DeleteRepeatableSQL(), name); err != nil {
+			return fmt.Errorf("failed to delete goose repeatable checksum: %w", err)
+		}
+	}
+	return nil
+}
+
+// upRepeatable applies the repeatable migrations whose checksum changed since they were last applied, in name order.
+func (p *Provider) upRepeatable(db *sql.DB, dir string, option *runState) error {
+	migrations, err := p.collectRepeatableFS(p.baseFS, dir)
+	if err != nil || len(migrations) == 0 {
+		return err
+	}
+	records, err := p.repeatableStatus(db, false)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		previous := records[path.Base(m.Source)].Checksum
+		if previous == m.checksum {
+			continue
+		}
+		if err := p.runBeforeAll(db, true, option); err != nil {
+			return err
+		}
+		apply := RepeatableApplyEvent{
+			Source:           m.Source,
+			Checksum:         m.checksum,
+			PreviousChecksum: previous,
+			ApplyAT:          time.Now(),
+		}
+		option.send(apply)
+		err := p.runWithCallbacks(db, m, true, option)
+		var hookErr ErrHook
+		committed := err == nil || errors.As(err, &hookErr) && hookErr.Committed
+		apply.ApplyAT = time.Now()
+		apply.Applied = committed
+		if !committed {
+			apply.Err = err
+		}
+		option.send(apply)
+		if !committed {
+			return err
+		}
+		option.applied++
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package goose
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRepeatableMigrations(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql":         {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/R_b_view.sql":        {Data: []byte("-- +goose Up\nDROP VIEW IF EXISTS b;\nCREATE VIEW b AS SELECT id FROM a;\n")},
+		"migrations/R_a_view.tpl.sql":    {Data: []byte("-- +goose Up\nDROP VIEW IF EXISTS c;\nCREATE VIEW c AS SELECT id FROM a;\n")},
+		"migrations/R_not_repeatable.go": {Data: []byte("package migrations\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+
+	// up runs Up, returning the sources of the repeatable migrations applied
+	up := func(t *testing.T) []string {
+		var applied []string
+		sub := p.Subscribe(func(e Eventer) {
+			if apply, ok := e.(RepeatableApplyEvent); ok && apply.Applied {
+				applied = append(applied, apply.Source)
+			}
+		})
+		defer p.Unsubscribe(sub)
+		if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		return applied
+	}
+
+	t.Run("applied in name order after versioned migrations", func(t *testing.T) {
+		expected := []string{"migrations/R_a_view.tpl.sql", "migrations/R_b_view.sql"}
+		if got := up(t); !equalStrings(got, expected) {
+			t.Errorf("applied, got %v expected %v", got, expected)
+		}
+		if _, err := db.Exec("SELECT id FROM b"); err != nil {
+			t.Errorf("view b: %v", err)
+		}
+	})
+	t.Run("unchanged are not applied", func(t *testing.T) {
+		if got := up(t); len(got) != 0 {
+			t.Errorf("applied, got %v expected none", got)
+		}
+	})
+	t.Run("status", func(t *testing.T) {
+		fsys["migrations/R_b_view.sql"].Data = []byte("-- +goose Up\nDROP VIEW IF EXISTS b;\nCREATE VIEW b AS SELECT id, id AS other FROM a;\n")
+		var got []StatusEvent
+		sub := p.Subscribe(func(e Eventer) {
+			if se, ok := e.(StatusEvent); ok {
+				got = append(got, se)
+			}
+		})
+		err := p.Status(db, "migrations", WithNoOutput())
+		p.Unsubscribe(sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("status, got %v expected 3 migrations", got)
+		}
+		for i, expected := range []struct {
+			source  string
+			changed bool
+		}{
+			{"migrations/R_a_view.tpl.sql", false},
+			{"migrations/R_b_view.sql", true},
+		} {
+			se := got[i+1]
+			if !se.Repeatable || se.Source != expected.source || se.Checksum == "" || se.Changed != expected.changed {
+				t.Errorf("status %d, got %+v expected %v, changed %v", i+1, se, expected.source, expected.changed)
+			}
+		}
+	})
+	t.Run("changed are applied again", func(t *testing.T) {
+		expected := []string{"migrations/R_b_view.sql"}
+		if got := up(t); !equalStrings(got, expected) {
+			t.Errorf("applied, got %v expected %v", got, expected)
+		}
+		if _, err := db.Exec("SELECT other FROM b"); err != nil {
+			t.Errorf("view b: %v", err)
+		}
+	})
+	t.Run("verify", func(t *testing.T) {
+		if status := p.Verify("migrations"); len(status.Findings) != 0 {
+			t.Errorf("findings, got %v expected none", status.Findings)
+		}
+	})
+	for _, tc := range []struct {
+		name     string
+		rollback func() error
+	}{
+		{"applied again after reset", func() error { return p.Reset(db, "migrations", WithNoOutput()) }},
+		{"applied again after down-to 0", func() error { return p.DownTo(db, "migrations", 0, WithNoOutput()) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.rollback(); err != nil {
+				t.Fatal(err)
+			}
+			expected := []string{"migrations/R_a_view.tpl.sql", "migrations/R_b_view.sql"}
+			if got := up(t); !equalStrings(got, expected) {
+				t.Errorf("applied, got %v expected %v", got, expected)
+			}
+			if _, err := db.Exec("SELECT other FROM b"); err != nil {
+				t.Errorf("view b: %v", err)
+			}
+		})
+	}
+}
+
+func TestRepeatableStatusError(t *testing.T) {
+	t.Parallel()
+	db := openSQLite(t)
+	p := NewProvider(Dialect(DialectSQLite3))
+
+	// a table that can not be queried is not created again
+	if _, err := db.Exec("CREATE TABLE " + TableName() + RepeatableTableSuffix + " (other TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.repeatableStatus(db, false); err == nil || !strings.Contains(err.Error(), "failed to query repeatable migrations") {
+		t.Errorf("error, got %v expected the query error", err)
+	}
+}
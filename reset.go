@@ -11,18 +11,26 @@ func Reset(db *sql.DB, dir string, opts ...OptionsFunc) error {
 	return defaultProvider.Reset(db, dir, opts...)
 }
 
-// Reset rolls back all migrations
-func (p *Provider) Reset(db *sql.DB, dir string, opts ...OptionsFunc) error {
-	option := applyOptions(opts)
+// Reset rolls back all migrations, the checksums of the repeatable migrations are deleted so the next
+// up applies them again.
+func (p *Provider) Reset(db *sql.DB, dir string, opts ...OptionsFunc) (err error) {
+	option := p.newRun(opts)
 	if option.shouldCloseEventsChannel() {
 		defer close(option.eventsChannel)
 	}
+	if err := option.checkReadOnly("reset"); err != nil {
+		return err
+	}
+	defer func() { err = p.finishCommand(db, option, "reset", true, err) }()
 	migrations, err := p.CollectMigrations(dir, minVersion, maxVersion)
 	if err != nil {
 		return fmt.Errorf("failed to collect migrations: %w", err)
 	}
+	if option.callbacks, err = p.collectSQLCallbacks(p.baseFS, dir); err != nil {
+		return err
+	}
 	if option.noVersioning {
-		return p.DownTo(db, dir, minVersion, append(opts, withDontCloseChannel())...)
+		return downToNoVersioning(p, db, migrations, minVersion, option)
 	}
 
 	statuses, err := dbMigrationsStatus(p.dialect, db)
@@ -31,20 +39,42 @@ func (p *Provider) Reset(db *sql.DB, dir string, opts ...OptionsFunc) error {
 	}
 	sort.Sort(sort.Reverse(migrations))
 
+	var applied Migrations
 	for _, migration := range migrations {
-		if !statuses[migration.Version] {
-			continue
+		if statuses[migration.Version] {
+			applied = append(applied, migration)
+		}
+	}
+	if len(applied) == 0 {
+		return p.clearRepeatable(db)
+	}
+	option.send(VersionCountEvent{
+		Version:           applied[0].Version,
+		VersionSource:     applied[0].Source,
+		TotalVersionsLeft: len(applied),
+	})
+
+	for i, migration := range applied {
+		apply := VersionApplyEvent{
+			From:       migration.Version,
+			FromSource: migration.Source,
+			Down:       true,
+			Versioned:  true,
+		}
+		if i+1 < len(applied) {
+			apply.To = applied[i+1].Version
+			apply.ToSource = applied[i+1].Source
 		}
-		if err = migration.DownWithProvider(p, db); err != nil {
-			return fmt.Errorf("failed to db-down: %w", err)
+		if err = p.applyMigration(db, migration, apply, option); err != nil {
+			return err
 		}
 	}
 
-	return nil
+	return p.clearRepeatable(db)
 }
 
 func dbMigrationsStatus(dialect SQLDialect, db *sql.DB) (map[int64]bool, error) {
-	rows, err := dialect.dbVersionQuery(db)
+	rows, err := dialect.DBVersionQuery(db)
 	if err != nil {
 		return map[int64]bool{}, nil
 	}
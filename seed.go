@@ -0,0 +1,181 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+const (
+	// DefaultSeedSet is the seed set made of the seed files directly in the seeds directory
+	DefaultSeedSet = "default"
+	// defaultSeedDir is the seeds directory, relative to the migrations directory
+	defaultSeedDir = "seeds"
+	// schemaVersionAnnotation declares the schema version a seed needs, e.g. -- +goose SchemaVersion: 5
+	schemaVersionAnnotation = "+goose SchemaVersion:"
+)
+
+var matchSeedSet = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// SeedDir sets the directory the seed sets are in, by default it is the seeds directory in the migrations directory.
+// Each sub directory is a named seed set, and the seed files directly in the directory are the default set.
+func SeedDir(dir string) func(p *Provider) {
+	return func(p *Provider) {
+		p.seedDir = dir
+	}
+}
+
+// Seed applies the seeds of the seed set, see Provider.Seed
+func Seed(db *sql.DB, dir string, set string, opts ...OptionsFunc) error {
+	return defaultProvider.Seed(db, dir, set, opts...)
+}
+
+// Seed applies the seeds of the named seed set, the default set if set is empty. Seed files are numbered, and written,
+// like migrations, and are tracked in their own version table for each set. A seed file can declare the schema version
+// it needs with a "-- +goose SchemaVersion: VERSION" annotation; the seeds are applied in order up to the first seed the
+// schema is not up to date for, for which an ErrSeedSchemaVersion is returned.
+func (p *Provider) Seed(db *sql.DB, dir string, set string, opts ...OptionsFunc) error {
+	if err := applyOptions(opts).checkReadOnly("seed"); err != nil {
+		return err
+	}
+	sp, setDir, err := p.seedProvider(dir, set)
+	if err != nil {
+		return err
+	}
+	seeds, err := sp.CollectMigrations(setDir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	schemaVersion, err := p.GetDBVersion(db)
+	if err != nil {
+		return err
+	}
+	for _, seed := range seeds {
+		required, err := sp.seedSchemaVersion(seed)
+		if err != nil {
+			return err
+		}
+		if required <= schemaVersion {
+			continue
+		}
+		seedErr := ErrSeedSchemaVersion{Source: seed.Source, Required: required, Current: schemaVersion}
+		if seed.Previous == -1 {
+			return seedErr
+		}
+		if err := sp.UpTo(db, setDir, seed.Previous, opts...); err != nil {
+			return err
+		}
+		return seedErr
+	}
+	return sp.Up(db, setDir, opts...)
+}
+
+// SeedReset rolls back all the seeds of the seed set, see Provider.SeedReset
+func SeedReset(db *sql.DB, dir string, set string, opts ...OptionsFunc) error {
+	return defaultProvider.SeedReset(db, dir, set, opts...)
+}
+
+// SeedReset rolls back all the seeds of the named seed set, the default set if set is empty.
+func (p *Provider) SeedReset(db *sql.DB, dir string, set string, opts ...OptionsFunc) error {
+	sp, setDir, err := p.seedProvider(dir, set)
+	if err != nil {
+		return err
+	}
+	return sp.Reset(db, setDir, opts...)
+}
+
+// SeedSets returns the names of the seed sets, see Provider.SeedSets
+func SeedSets(dir string) ([]string, error) { return defaultProvider.SeedSets(dir) }
+
+// SeedSets returns the names of the seed sets, the default set is only returned if it has seed files.
+func (p *Provider) SeedSets(dir string) ([]string, error) {
+	seedDir := p.seedDirectory(dir)
+	entries, err := fs.ReadDir(p.baseFS, seedDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sets []string
+	hasDefault := false
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir() && matchSeedSet.MatchString(entry.Name()):
+			sets = append(sets, entry.Name())
+		case !entry.IsDir() && path.Ext(entry.Name()) == ".sql":
+			hasDefault = true
+		}
+	}
+	if hasDefault {
+		sets = append(sets, DefaultSeedSet)
+	}
+	sort.Strings(sets)
+	return sets, nil
+}
+
+// seedDirectory returns the directory the seed sets are in
+func (p *Provider) seedDirectory(dir string) string {
+	if p.seedDir != "" {
+		return p.seedDir
+	}
+	return path.Join(dir, defaultSeedDir)
+}
+
+// seedProvider returns a provider for applying the seeds of the set, and the directory of the set.
+// The provider shares the subscribers of p, but has its own version table and no registered go migrations.
+func (p *Provider) seedProvider(dir string, set string) (*Provider, string, error) {
+	if set == "" {
+		set = DefaultSeedSet
+	}
+	if !matchSeedSet.MatchString(set) {
+		return nil, "", fmt.Errorf("%q: seed set names may only contain letters, digits and underscores", set)
+	}
+	setDir := p.seedDirectory(dir)
+	if set != DefaultSeedSet {
+		setDir = path.Join(setDir, set)
+	}
+
+	sp := *p
+	sp.tableName = p.tableName + "_seed"
+	if set != DefaultSeedSet {
+		sp.tableName += "_" + set
+	}
+	sp.dialect = cloneDialect(p.dialect)
+	sp.dialect.SetTableName(sp.tableName)
+	sp.registeredGoMigrations = map[int64]*Migration{}
+	return &sp, setDir, nil
+}
+
+// cloneDialect returns a copy of the dialect, so its table name can be changed
+func cloneDialect(d SQLDialect) SQLDialect {
+	v := reflect.ValueOf(d)
+	if v.Kind() != reflect.Ptr {
+		return d
+	}
+	clone := reflect.New(v.Elem().Type())
+	clone.Elem().Set(v.Elem())
+	return clone.Interface().(SQLDialect)
+}
+
+// seedSchemaVersion returns the schema version the seed declares it needs, zero if it does not declare one
+func (p *Provider) seedSchemaVersion(seed *Migration) (int64, error) {
+	value, found, err := readAnnotation(p.baseFS, seed.Source, schemaVersionAnnotation)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read seed file: %w", err)
+	}
+	if !found {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid schema version %q: %w", seed.Source, value, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,93 @@
+package goose
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSeed(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql":            {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/00002_b.sql":            {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+		"migrations/seeds/00001_a.sql":      {Data: []byte("-- +goose SchemaVersion: 1\n-- +goose Up\nINSERT INTO a (id) VALUES (1);\n-- +goose Down\nDELETE FROM a WHERE id = 1;\n")},
+		"migrations/seeds/00002_b.sql":      {Data: []byte("-- +goose SchemaVersion: 2\n-- +goose Up\nINSERT INTO b (id) VALUES (1);\n-- +goose Down\nDELETE FROM b WHERE id = 1;\n")},
+		"migrations/seeds/dev/00001_a.sql":  {Data: []byte("-- +goose Up\nINSERT INTO a (id) VALUES (100);\n-- +goose Down\nDELETE FROM a WHERE id = 100;\n")},
+		"migrations/seeds/not-a-set/01.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+
+	count := func(t *testing.T, table string) int {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	t.Run("sets", func(t *testing.T) {
+		sets, err := p.SeedSets("migrations")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"default", "dev"}; !equalStrings(sets, expected) {
+			t.Errorf("sets, got %v expected %v", sets, expected)
+		}
+	})
+	t.Run("stops at schema version", func(t *testing.T) {
+		if err := p.UpTo(db, "migrations", 1, WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		err := p.Seed(db, "migrations", "", WithNoOutput())
+		var serr ErrSeedSchemaVersion
+		if !errors.As(err, &serr) || serr.Required != 2 || serr.Current != 1 {
+			t.Fatalf("error, got %v expected an ErrSeedSchemaVersion for version 2", err)
+		}
+		if n := count(t, "a"); n != 1 {
+			t.Errorf("rows in a, got %d expected 1", n)
+		}
+	})
+	t.Run("applies remaining seeds", func(t *testing.T) {
+		if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Seed(db, "migrations", DefaultSeedSet, WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if n := count(t, "b"); n != 1 {
+			t.Errorf("rows in b, got %d expected 1", n)
+		}
+		if version, err := p.GetDBVersion(db); err != nil || version != 2 {
+			t.Errorf("schema version, got %d, %v expected 2", version, err)
+		}
+	})
+	t.Run("named set", func(t *testing.T) {
+		if err := p.Seed(db, "migrations", "dev", WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if n := count(t, "a"); n != 2 {
+			t.Errorf("rows in a, got %d expected 2", n)
+		}
+		if err := p.SeedReset(db, "migrations", "dev", WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if n := count(t, "a"); n != 1 {
+			t.Errorf("rows in a, got %d expected 1", n)
+		}
+	})
+	t.Run("reset", func(t *testing.T) {
+		if err := p.SeedReset(db, "migrations", "", WithNoOutput()); err != nil {
+			t.Fatal(err)
+		}
+		if n := count(t, "a") + count(t, "b"); n != 0 {
+			t.Errorf("rows, got %d expected 0", n)
+		}
+	})
+	t.Run("invalid set", func(t *testing.T) {
+		if err := p.Seed(db, "migrations", "not-a-set", WithNoOutput()); err == nil {
+			t.Errorf("error, got nil expected an invalid seed set name")
+		}
+	})
+}
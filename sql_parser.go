@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"regexp"
 	"strings"
 	"sync"
@@ -46,6 +47,41 @@ var bufferPool = sync.Pool{
 	},
 }
 
+// readAnnotation returns the value of the first "-- +goose Name: value" annotation in the file, annotation
+// being the "+goose Name:" part. found is false if the file does not have the annotation.
+func readAnnotation(fsys fs.FS, source, annotation string) (value string, found bool, err error) {
+	values, err := readAnnotations(fsys, source, annotation)
+	value, found = values[annotation]
+	return value, found, err
+}
+
+// readAnnotations returns the values of the first of each of the "-- +goose Name: value" annotations in
+// the file, by annotation. Annotations the file does not have are not in the returned map.
+func readAnnotations(fsys fs.FS, source string, annotations ...string) (map[string]string, error) {
+	f, err := fsys.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, scanBufSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "--") {
+			continue
+		}
+		cmd := strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		for _, annotation := range annotations {
+			if _, seen := values[annotation]; !seen && strings.HasPrefix(cmd, annotation) {
+				values[annotation] = strings.TrimSpace(strings.TrimPrefix(cmd, annotation))
+			}
+		}
+	}
+	return values, scanner.Err()
+}
+
 // parseSQLMigration will split the given SQL-script into individual statements and return
 // SQL statements for given direction (up=true, down=false).
 //
@@ -57,6 +93,13 @@ var bufferPool = sync.Pool{
 // 'StatementBegin' and 'StatementEnd' to allow the script to
 // tell us to ignore semicolons.
 func parseSQLMigration(p *Provider, r io.Reader, direction bool) (stmts []string, useTx bool, err error) {
+	stmts, _, useTx, err = parseSQLMigrationLines(p, r, direction)
+	return stmts, useTx, err
+}
+
+// parseSQLMigrationLines is parseSQLMigration, but also returns the line in the file each of the
+// statements starts on.
+func parseSQLMigrationLines(p *Provider, r io.Reader, direction bool) (stmts []string, lines []int, useTx bool, err error) {
 	if p == nil {
 		p = defaultProvider
 	}
@@ -70,7 +113,15 @@ func parseSQLMigration(p *Provider, r io.Reader, direction bool) (stmts []string
 	stateMachine := stateMachine(start)
 	useTx = true
 
+	// lineNum is the current line, beginLine the line of the last StatementBegin annotation,
+	// and stmtLine the line the statement in buf starts on
+	var lineNum, beginLine, stmtLine int
+	lineErr := func(line int, err error) error {
+		return ErrSQLParseLine{Line: line, ErrUnwrap: ErrUnwrap{err}}
+	}
+
 	for scanner.Scan() {
+		lineNum++
 		line := scanner.Text()
 		if p.verbose {
 			p.log.Println(line)
@@ -85,7 +136,7 @@ func parseSQLMigration(p *Provider, r io.Reader, direction bool) (stmts []string
 				case start:
 					stateMachine.Set(gooseUp)
 				default:
-					return nil, false, fmt.Errorf("duplicate '-- +goose Up' annotations; stateMachine=%v, see https://github.com/pressly/goose#sql-migrations", stateMachine)
+					return nil, nil, false, lineErr(lineNum, fmt.Errorf("duplicate '-- +goose Up' annotations; stateMachine=%v, see https://github.com/pressly/goose#sql-migrations", stateMachine))
 				}
 				continue
 
@@ -94,7 +145,7 @@ func parseSQLMigration(p *Provider, r io.Reader, direction bool) (stmts []string
 				case gooseUp, gooseStatementEndUp:
 					stateMachine.Set(gooseDown)
 				default:
-					return nil, false, fmt.Errorf("must start with '-- +goose Up' annotation, stateMachine=%v, see https://github.com/pressly/goose#sql-migrations", stateMachine)
+					return nil, nil, false, lineErr(lineNum, fmt.Errorf("must start with '-- +goose Up' annotation, stateMachine=%v, see https://github.com/pressly/goose#sql-migrations", stateMachine))
 				}
 				continue
 
@@ -102,10 +153,12 @@ func parseSQLMigration(p *Provider, r io.Reader, direction bool) (stmts []string
 				switch stateMachine.Get() {
 				case gooseUp, gooseStatementEndUp:
 					stateMachine.Set(gooseStatementBeginUp)
+					beginLine = lineNum
 				case gooseDown, gooseStatementEndDown:
 					stateMachine.Set(gooseStatementBeginDown)
+					beginLine = lineNum
 				default:
-					return nil, false, fmt.Errorf("'-- +goose StatementBegin' must be defined after '-- +goose Up' or '-- +goose Down' annotation, stateMachine=%v, see https://github.com/pressly/goose#sql-migrations", stateMachine)
+					return nil, nil, false, lineErr(lineNum, fmt.Errorf("'-- +goose StatementBegin' must be defined after '-- +goose Up' or '-- +goose Down' annotation, stateMachine=%v, see https://github.com/pressly/goose#sql-migrations", stateMachine))
 				}
 				continue
 
@@ -116,7 +169,7 @@ func parseSQLMigration(p *Provider, r io.Reader, direction bool) (stmts []string
 				case gooseStatementBeginDown:
 					stateMachine.Set(gooseStatementEndDown)
 				default:
-					return nil, false, errors.New("'-- +goose StatementEnd' must be defined after '-- +goose StatementBegin', see https://github.com/pressly/goose#sql-migrations")
+					return nil, nil, false, lineErr(lineNum, errors.New("'-- +goose StatementEnd' must be defined after '-- +goose StatementBegin', see https://github.com/pressly/goose#sql-migrations"))
 				}
 
 			case "+goose NO TRANSACTION":
@@ -137,8 +190,11 @@ func parseSQLMigration(p *Provider, r io.Reader, direction bool) (stmts []string
 		}
 
 		// Write SQL line to a buffer.
+		if buf.Len() == 0 {
+			stmtLine = lineNum
+		}
 		if _, err := buf.WriteString(line + "\n"); err != nil {
-			return nil, false, fmt.Errorf("failed to write to buf: %w", err)
+			return nil, nil, false, lineErr(lineNum, fmt.Errorf("failed to write to buf: %w", err))
 		}
 
 		// Read SQL body one by line, if we're in the right direction.
@@ -160,51 +216,55 @@ func parseSQLMigration(p *Provider, r io.Reader, direction bool) (stmts []string
 				continue
 			}
 		default:
-			return nil, false, fmt.Errorf("failed to parse migration: unexpected state %q on line %q, see https://github.com/pressly/goose#sql-migrations", stateMachine, line)
+			return nil, nil, false, lineErr(lineNum, fmt.Errorf("failed to parse migration: unexpected state %q on line %q, see https://github.com/pressly/goose#sql-migrations", stateMachine, line))
 		}
 
 		switch stateMachine.Get() {
 		case gooseUp:
 			if endsWithSemicolon(line) {
 				stmts = append(stmts, buf.String())
+				lines = append(lines, stmtLine)
 				buf.Reset()
 				p.verboseInfo("StateMachine: store simple Up query")
 			}
 		case gooseDown:
 			if endsWithSemicolon(line) {
 				stmts = append(stmts, buf.String())
+				lines = append(lines, stmtLine)
 				buf.Reset()
 				p.verboseInfo("StateMachine: store simple Down query")
 			}
 		case gooseStatementEndUp:
 			stmts = append(stmts, buf.String())
+			lines = append(lines, stmtLine)
 			buf.Reset()
 			p.verboseInfo("StateMachine: store Up statement")
 			stateMachine.Set(gooseUp)
 		case gooseStatementEndDown:
 			stmts = append(stmts, buf.String())
+			lines = append(lines, stmtLine)
 			buf.Reset()
 			p.verboseInfo("StateMachine: store Down statement")
 			stateMachine.Set(gooseDown)
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, false, fmt.Errorf("failed to scan migration: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to scan migration: %w", err)
 	}
 	// EOF
 
 	switch stateMachine.Get() {
 	case start:
-		return nil, false, errors.New("failed to parse migration: must start with '-- +goose Up' annotation, see https://github.com/pressly/goose#sql-migrations")
+		return nil, nil, false, lineErr(1, errors.New("failed to parse migration: must start with '-- +goose Up' annotation, see https://github.com/pressly/goose#sql-migrations"))
 	case gooseStatementBeginUp, gooseStatementBeginDown:
-		return nil, false, errors.New("failed to parse migration: missing '-- +goose StatementEnd' annotation")
+		return nil, nil, false, lineErr(beginLine, errors.New("failed to parse migration: missing '-- +goose StatementEnd' annotation"))
 	}
 
 	if bufferRemaining := strings.TrimSpace(buf.String()); len(bufferRemaining) > 0 {
-		return nil, false, fmt.Errorf("failed to parse migration: state %q, direction: %v: unexpected unfinished SQL query: %q: missing semicolon?", stateMachine, direction, bufferRemaining)
+		return nil, nil, false, lineErr(lineNum, fmt.Errorf("failed to parse migration: state %q, direction: %v: unexpected unfinished SQL query: %q: missing semicolon?", stateMachine, direction, bufferRemaining))
 	}
 
-	return stmts, useTx, nil
+	return stmts, lines, useTx, nil
 }
 
 // Checks the line to see if the line has a statement-ending semicolon
@@ -2,32 +2,52 @@ package goose
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 const (
 	noVersioning   = "no versioning"
 	pendingVersion = "pending"
+	skippedVersion = "skipped by tag"
 )
 
 // StatusEvent is a version number, and source of a
 // migration, and whether it has been applied
 type StatusEvent struct {
-	*Event
+	*Event `json:"-"`
 
 	// Source is the full path of the source file, use `Script` to get the name
-	Source string
+	Source string `json:"source"`
 	// Version is the version number, it should be -1 if not set
-	Version   int64
-	Versioned bool
+	Version   int64 `json:"version"`
+	Versioned bool  `json:"versioned"`
 	// If not zero then the time the migration was applied at
-	AppliedAt time.Time
+	AppliedAt time.Time `json:"applied_at"`
+	// Repeatable is set for repeatable migrations, which have no version. Checksum is the
+	// checksum of the repeatable migration the last time it was applied, and Changed is set
+	// if the migration has changed since then.
+	Repeatable bool   `json:"repeatable,omitempty"`
+	Checksum   string `json:"checksum,omitempty"`
+	Changed    bool   `json:"changed,omitempty"`
+	// Tags are the tags of the migration, and Skipped is set if the migration has not been applied
+	// and would not be applied because of its tags, see WithTags and WithoutTags.
+	Tags    []string `json:"tags,omitempty"`
+	Skipped bool     `json:"skipped,omitempty"`
+	// Provider is the name of the provider the migration belongs to, when the status is of a ProviderGroup
+	Provider string `json:"provider,omitempty"`
+	// Orphaned is set for a version applied to the database that has no migration, its Source is empty
+	Orphaned bool `json:"orphaned,omitempty"`
 }
 
 func (se StatusEvent) AppliedString() string {
-	if se.AppliedAt.IsZero() {
+	if se.Skipped {
+		return skippedVersion
+	}
+	if se.AppliedAt.IsZero() || se.Changed {
 		return pendingVersion
 	}
 	return se.AppliedAt.Format(time.ANSIC)
@@ -44,7 +64,12 @@ func (se StatusEvent) String() string {
 	return fmt.Sprintf("%s : %s (%d)", se.VersionedString(), se.Source, se.Version)
 }
 
-func (se StatusEvent) Script() string { return filepath.Base(se.Source) }
+func (se StatusEvent) Script() string {
+	if se.Orphaned {
+		return fmt.Sprintf("%d (orphaned, no migration)", se.Version)
+	}
+	return filepath.Base(se.Source)
+}
 
 func (se StatusEvent) IsEqual(e Eventer) bool {
 	otherSE, ok := e.(StatusEvent)
@@ -59,7 +84,27 @@ func (se StatusEvent) IsEqual(e Eventer) bool {
 	return se.Versioned == otherSE.Versioned &&
 		se.AppliedAt.IsZero() == otherSE.AppliedAt.IsZero() &&
 		se.Version == otherSE.Version &&
-		se.Source == otherSE.Source
+		se.Source == otherSE.Source &&
+		se.Repeatable == otherSE.Repeatable &&
+		se.Checksum == otherSE.Checksum &&
+		se.Changed == otherSE.Changed &&
+		se.Skipped == otherSE.Skipped &&
+		se.Provider == otherSE.Provider &&
+		se.Orphaned == otherSE.Orphaned &&
+		equalTags(se.Tags, otherSE.Tags)
+}
+
+// equalTags returns if both lists have the same tags, in the same order
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 var (
@@ -72,83 +117,177 @@ func Status(db *sql.DB, dir string, opts ...OptionsFunc) error {
 	return defaultProvider.Status(db, dir, opts...)
 }
 
-func (p *Provider) Status(db *sql.DB, dir string, opts ...OptionsFunc) (err error) {
+func (p *Provider) Status(db *sql.DB, dir string, opts ...OptionsFunc) error {
 	if p == nil {
 		return nil
 	}
-	var events = make(chan Eventer)
-	var options = applyOptions(opts)
+	var options = p.applyOptions(opts)
 	if options.shouldCloseEventsChannel() {
 		defer close(options.eventsChannel)
 	}
-	go func() {
-		err = p.eventsStatus(db, dir, events, options.noVersioning)
-	}()
 	if !options.noOutput {
 		p.log.Println("    Applied At                  Migration")
 		p.log.Println("    =======================================")
 	}
-	for event := range events {
-		options.send(event)
-		current, ok := event.(StatusEvent)
-		if !ok {
-			continue
+	err := p.eventsStatus(db, dir, options, func(current StatusEvent) {
+		options.send(current)
+		if options.noOutput {
+			return
 		}
-
+		if current.Repeatable && current.Checksum != "" {
+			p.log.Printf("    %-24s -- %v (checksum %.12s)\n", current.AppliedString(), current.Script(), current.Checksum)
+			return
+		}
+		if current.Skipped {
+			p.log.Printf("    %-24s -- %v (tags %s)\n", current.AppliedString(), current.Script(), strings.Join(current.Tags, ","))
+			return
+		}
+		p.log.Printf("    %-24s -- %v\n", current.AppliedString(), current.Script())
+	})
+	if errors.As(err, &ErrNotInitialized{}) {
 		if !options.noOutput {
-			p.log.Printf("    %-24s -- %v\n", current.AppliedString(), current.Script())
+			p.log.Printf("goose: not initialized, version table %s does not exist\n", p.tableName)
 		}
+		return nil
 	}
 	return err
 }
 
-// eventsStatus will send events to the provided channel, closing the channel after all events or an error is encountered.
-// If an error is encountered it will be returned by the function
-func (p *Provider) eventsStatus(db *sql.DB, dir string, eventsChannel chan<- Eventer, noVersioning bool) error {
-	if eventsChannel == nil {
-		return nil
-	}
-	defer close(eventsChannel)
-
+// eventsStatus will call emit with the status of each migration, in order, the tags the
+// migrations are filtered with are taken from option.
+// If an error is encountered it will be returned by the function. When run read-only and the
+// version table does not exist, all the migrations are emitted as pending and an ErrNotInitialized
+// is returned.
+func (p *Provider) eventsStatus(db *sql.DB, dir string, option *options, emit func(StatusEvent)) error {
 	migrations, err := p.CollectMigrations(dir, minVersion, maxVersion)
 
 	if err != nil {
 		return fmt.Errorf("failed to collect migrations: %w", err)
 	}
-	if noVersioning || db == nil {
+	repeatable, err := p.collectRepeatableFS(p.baseFS, dir)
+	if err != nil {
+		return fmt.Errorf("failed to collect repeatable migrations: %w", err)
+	}
+	if option.noVersioning || db == nil {
 		for _, current := range migrations {
-			eventsChannel <- StatusEvent{
+			emit(StatusEvent{
 				Source:    current.Source,
 				Version:   current.Version,
 				Versioned: false,
-			}
+				Tags:      current.Tags,
+				Skipped:   option.skippedByTag(current),
+			})
+		}
+		for _, current := range repeatable {
+			emit(StatusEvent{
+				Source:     current.Source,
+				Version:    -1,
+				Repeatable: true,
+			})
 		}
 		return nil
 	}
 
-	// must ensure that the version table exists if we're running on a pristine DB
-	if _, err := p.EnsureDBVersion(db); err != nil {
+	// must ensure that the version table exists if we're running on a pristine DB, unless the
+	// database must not be changed
+	if _, err := p.dbVersion(db, option.readOnly); err != nil {
+		if nerr := (ErrNotInitialized{}); errors.As(err, &nerr) {
+			for _, current := range migrations {
+				emit(StatusEvent{
+					Source:    current.Source,
+					Version:   current.Version,
+					Versioned: true,
+					Tags:      current.Tags,
+					Skipped:   option.skippedByTag(current),
+				})
+			}
+			for _, current := range repeatable {
+				emit(StatusEvent{
+					Source:     current.Source,
+					Version:    -1,
+					Versioned:  true,
+					Repeatable: true,
+				})
+			}
+			return nerr
+		}
 		return fmt.Errorf("failed to ensure DB version: %w", err)
 	}
 
 	// we have a db so, let's get the versions of the database
-	q := p.dialect.migrationSQL()
-	for _, current := range migrations {
+	q := p.dialect.MigrationSQL()
+	appliedAt := func(version int64) (time.Time, error) {
 		var (
 			isApplied bool
 			at        time.Time
 		)
-		err := db.QueryRow(q, current.Version).Scan(&at, &isApplied)
+		err := db.QueryRow(q, version).Scan(&at, &isApplied)
 		if err != nil && err != sql.ErrNoRows {
-			return fmt.Errorf("failed to query the latest migration: %w", err)
+			return at, fmt.Errorf("failed to query the latest migration: %w", err)
+		}
+		return at, nil
+	}
+	// the versions applied that have no migration are emitted as orphaned, in order of version
+	orphans, err := p.orphanedVersions(db, migrations)
+	if err != nil {
+		return err
+	}
+	emitOrphans := func(before int64) error {
+		for len(orphans) > 0 && orphans[0] < before {
+			at, err := appliedAt(orphans[0])
+			if err != nil {
+				return err
+			}
+			emit(StatusEvent{
+				Version:   orphans[0],
+				Versioned: true,
+				AppliedAt: at,
+				Orphaned:  true,
+			})
+			orphans = orphans[1:]
+		}
+		return nil
+	}
+	for _, current := range migrations {
+		if err := emitOrphans(current.Version); err != nil {
+			return err
+		}
+		at, err := appliedAt(current.Version)
+		if err != nil {
+			return err
 		}
 
-		eventsChannel <- StatusEvent{
+		emit(StatusEvent{
 			Source:    current.Source,
 			Version:   current.Version,
 			Versioned: true,
 			AppliedAt: at,
-		}
+			Tags:      current.Tags,
+			Skipped:   at.IsZero() && option.skippedByTag(current),
+		})
+	}
+	if err := emitOrphans(maxVersion); err != nil {
+		return err
+	}
+
+	if len(repeatable) == 0 {
+		return nil
+	}
+	records, err := p.repeatableStatus(db, option.readOnly)
+	if err != nil {
+		return err
+	}
+	for _, current := range repeatable {
+		record := records[filepath.Base(current.Source)]
+		emit(StatusEvent{
+			Source:     current.Source,
+			Version:    -1,
+			Versioned:  true,
+			AppliedAt:  record.AppliedAt,
+			Repeatable: true,
+			Checksum:   record.Checksum,
+			Changed:    record.Checksum != "" && record.Checksum != current.checksum,
+		})
 	}
 	return nil
 }
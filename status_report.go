@@ -0,0 +1,168 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MigrationType is the kind of source a migration is written in
+type MigrationType string
+
+const (
+	MigrationTypeSQL      MigrationType = "sql"
+	MigrationTypeTemplate MigrationType = "tpl"
+	MigrationTypeGo       MigrationType = "go"
+)
+
+// migrationTypeOf returns the type of the migration's source, "" if it is not known
+func migrationTypeOf(source string) MigrationType {
+	switch getExtension(source) {
+	case ".sql":
+		return MigrationTypeSQL
+	case ".tpl.sql":
+		return MigrationTypeTemplate
+	case ".go":
+		return MigrationTypeGo
+	}
+	return ""
+}
+
+// MigrationState is the state of a migration in a StatusReport
+type MigrationState string
+
+const (
+	// StatePending migrations have not been applied
+	StatePending MigrationState = "pending"
+	// StateApplied migrations have been applied
+	StateApplied MigrationState = "applied"
+	// StateMissing migrations have not been applied, but a later version has, see WithAllowMissing
+	StateMissing MigrationState = "missing-out-of-order"
+	// StateOrphaned versions have been applied to the database, but have no migration
+	StateOrphaned MigrationState = "orphaned"
+	// StateSkippedByTag migrations have not been applied, and are not applied by up with the tags of the
+	// report, see WithTags and WithoutTags
+	StateSkippedByTag MigrationState = "skipped-by-tag"
+)
+
+// StatusRow is the status of a migration, or of an orphaned version, in a StatusReport
+type StatusRow struct {
+	// Version is the version of the migration, -1 for a repeatable migration
+	Version int64 `json:"version" yaml:"version"`
+	// Source is the path of the migration's source, empty for an orphaned version
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// Type is the type of the source, empty for an orphaned version
+	Type MigrationType `json:"type,omitempty" yaml:"type,omitempty"`
+	// AppliedAt is the time the migration was applied at, nil if it has not been
+	AppliedAt *time.Time     `json:"applied_at,omitempty" yaml:"applied_at,omitempty"`
+	State     MigrationState `json:"state" yaml:"state"`
+	Tags      []string       `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Repeatable is set for repeatable migrations, which are pending again once they have changed
+	Repeatable bool `json:"repeatable,omitempty" yaml:"repeatable,omitempty"`
+}
+
+// Applied returns if the row's version is applied to the database
+func (r StatusRow) Applied() bool { return r.State == StateApplied || r.State == StateOrphaned }
+
+// StatusReport returns the status of all the migrations, see Provider.StatusReport
+func StatusReport(db *sql.DB, dir string, opts ...OptionsFunc) ([]StatusRow, error) {
+	return defaultProvider.StatusReport(db, dir, opts...)
+}
+
+// StatusReport returns the status of all the migrations ordered by version, the versions applied to the
+// database that have no migration included as orphaned, followed by the repeatable migrations. Nothing is
+// printed or sent as events, a channel given with WithEvents is closed unless asked not to be. If run read-only, see WithReadOnly, and the version table does not exist, all
+// the migrations are returned as pending along with an ErrNotInitialized.
+func (p *Provider) StatusReport(db *sql.DB, dir string, opts ...OptionsFunc) ([]StatusRow, error) {
+	if p == nil {
+		return nil, nil
+	}
+	option := applyOptions(opts)
+	// no events are sent, the events channel is closed as by the other commands
+	if option.shouldCloseEventsChannel() {
+		defer close(option.eventsChannel)
+	}
+	option.eventsChannel = nil
+
+	var rows, repeatable []StatusRow
+	err := p.eventsStatus(db, dir, option, func(current StatusEvent) {
+		row := StatusRow{
+			Version:    current.Version,
+			Source:     current.Source,
+			Type:       migrationTypeOf(current.Source),
+			State:      StateApplied,
+			Tags:       current.Tags,
+			Repeatable: current.Repeatable,
+		}
+		if !current.AppliedAt.IsZero() {
+			at := current.AppliedAt
+			row.AppliedAt = &at
+		}
+		switch {
+		case current.Orphaned:
+			row.State = StateOrphaned
+		case current.Skipped:
+			row.State = StateSkippedByTag
+		case current.AppliedAt.IsZero() || current.Changed:
+			row.State = StatePending
+		}
+		if current.Repeatable {
+			repeatable = append(repeatable, row)
+			return
+		}
+		rows = append(rows, row)
+	})
+	if errors.As(err, &ErrNotInitialized{}) {
+		return append(rows, repeatable...), err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the rows are ordered by version, the pending migrations before the last version applied are missing
+	last := -1
+	for i, row := range rows {
+		if row.Applied() {
+			last = i
+		}
+	}
+	for i := 0; i < last; i++ {
+		if rows[i].State == StatePending {
+			rows[i].State = StateMissing
+		}
+	}
+	return append(rows, repeatable...), nil
+}
+
+// dbAppliedVersions returns the versions applied to the database, ordered by version, the most recent row
+// of each version deciding whether it is applied. Version 0, inserted when the version table is created,
+// is not returned.
+func (p *Provider) dbAppliedVersions(db *sql.DB) ([]int64, error) {
+	rows, err := p.dialect.DBVersionQuery(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the versions: %w", err)
+	}
+	defer rows.Close()
+	seen := make(map[int64]bool)
+	var applied []int64
+	for rows.Next() {
+		var row MigrationRecord
+		if err := rows.Scan(&row.VersionID, &row.IsApplied); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if seen[row.VersionID] {
+			continue
+		}
+		seen[row.VersionID] = true
+		if row.IsApplied && row.VersionID != 0 {
+			applied = append(applied, row.VersionID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get next row: %w", err)
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i] < applied[j] })
+	return applied, nil
+}
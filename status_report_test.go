@@ -0,0 +1,70 @@
+package goose
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatusReport(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/00001_users.sql":     {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n")},
+		"migrations/00002_posts.tpl.sql": {Data: []byte("-- +goose Up\n-- +goose Tags: dev\nCREATE TABLE posts (id INTEGER);\n-- +goose Down\nDROP TABLE posts;\n")},
+		"migrations/00003_accounts.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE accounts (id INTEGER);\n-- +goose Down\nDROP TABLE accounts;\n")},
+		"migrations/00004_comments.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE comments (id INTEGER);\n-- +goose Down\nDROP TABLE comments;\n")},
+		"migrations/R_refresh_views.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+
+	events := make(chan Eventer, 1)
+	rows, err := p.StatusReport(db, "migrations", WithReadOnly(), WithEvents(events, false))
+	for e := range events {
+		t.Errorf("events, got %+v expected none", e)
+	}
+	if !errors.As(err, &ErrNotInitialized{}) || len(rows) != 5 {
+		t.Fatalf("read-only report, got %d rows, %v expected 5 rows and an ErrNotInitialized", len(rows), err)
+	}
+
+	// 00002 is skipped by its tag, and 00009 is applied without a migration
+	if err := p.Up(db, "migrations", WithNoOutput(), WithoutTags("dev")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO goose_db_version (version_id, is_applied) VALUES (9, true)"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = p.StatusReport(db, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []StatusRow{
+		{Version: 1, Type: MigrationTypeSQL, State: StateApplied},
+		{Version: 2, Type: MigrationTypeTemplate, State: StateMissing, Tags: []string{"dev"}},
+		{Version: 3, Type: MigrationTypeSQL, State: StateApplied},
+		{Version: 4, Type: MigrationTypeSQL, State: StateApplied},
+		{Version: 9, State: StateOrphaned},
+		{Version: -1, Type: MigrationTypeSQL, State: StateApplied, Repeatable: true},
+	}
+	expectRows := func(rows, expected []StatusRow) {
+		t.Helper()
+		if len(rows) != len(expected) {
+			t.Fatalf("rows, got %+v expected %d rows", rows, len(expected))
+		}
+		for i, row := range rows {
+			e := expected[i]
+			if row.Version != e.Version || row.Type != e.Type || row.State != e.State || row.Repeatable != e.Repeatable ||
+				!equalStrings(row.Tags, e.Tags) || (row.AppliedAt != nil) != row.Applied() {
+				t.Errorf("row %d, got %+v expected %+v", i, row, e)
+			}
+		}
+	}
+	expectRows(rows, expected)
+
+	// with the tags of the up 00002 is skipped rather than missing
+	rows, err = p.StatusReport(db, "migrations", WithoutTags("dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected[1].State = StateSkippedByTag
+	expectRows(rows, expected)
+}
@@ -0,0 +1,148 @@
+package goose
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventHandler is called with each event sent by the commands run on a Provider.
+type EventHandler func(e Eventer)
+
+// SubscribeOption configures a Subscription
+type SubscribeOption func(s *Subscription)
+
+// SubscribeBuffer makes the subscription queue up to size events, the handler is then called on its own
+// goroutine, instead of on the goroutine running the command. By default, once the buffer is full the
+// command blocks until the handler catches up; use SubscribeDropWhenFull to drop the events instead.
+func SubscribeBuffer(size int) SubscribeOption {
+	return func(s *Subscription) {
+		if size < 0 {
+			size = 0
+		}
+		s.size = size
+	}
+}
+
+// SubscribeDropWhenFull makes the subscription drop events, instead of blocking the command, when its
+// buffer is full. Without a buffer, see SubscribeBuffer, every event is dropped that arrives while the handler
+// is still busy with the previous one.
+func SubscribeDropWhenFull() SubscribeOption {
+	return func(s *Subscription) {
+		s.drop = true
+	}
+}
+
+// Subscription is a handler registered with Provider.Subscribe
+type Subscription struct {
+	handler EventHandler
+	size    int
+	drop    bool
+	dropped int64
+
+	// queue is nil for subscriptions that are called directly, and no events are dropped
+	queue chan Eventer
+	done  chan struct{}
+}
+
+// Dropped returns the number of events dropped because the subscription was full
+func (s *Subscription) Dropped() int64 { return atomic.LoadInt64(&s.dropped) }
+
+func (s *Subscription) start() {
+	if s.size == 0 && !s.drop {
+		return
+	}
+	s.queue = make(chan Eventer, s.size)
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		for e := range s.queue {
+			s.handler(e)
+		}
+	}()
+}
+
+// stop waits for the queued events to be handled
+func (s *Subscription) stop() {
+	if s.queue == nil {
+		return
+	}
+	close(s.queue)
+	<-s.done
+}
+
+func (s *Subscription) publish(e Eventer) {
+	switch {
+	case s.queue == nil:
+		s.handler(e)
+	case s.drop:
+		select {
+		case s.queue <- e:
+		default:
+			atomic.AddInt64(&s.dropped, 1)
+		}
+	default:
+		s.queue <- e
+	}
+}
+
+// eventBus is the set of subscriptions of a provider
+type eventBus struct {
+	lck           sync.RWMutex
+	subscriptions []*Subscription
+}
+
+func (b *eventBus) publish(e Eventer) {
+	if b == nil {
+		return
+	}
+	b.lck.RLock()
+	defer b.lck.RUnlock()
+	for _, s := range b.subscriptions {
+		s.publish(e)
+	}
+}
+
+// Subscribe registers the handler to be called with the events of every command run with the general functions.
+func Subscribe(handler EventHandler, opts ...SubscribeOption) *Subscription {
+	return defaultProvider.Subscribe(handler, opts...)
+}
+
+// Unsubscribe removes a subscription made with Subscribe.
+func Unsubscribe(s *Subscription) { defaultProvider.Unsubscribe(s) }
+
+// Subscribe registers the handler to be called with the events of every command run on the provider,
+// until it is unsubscribed. Any number of handlers can be subscribed; without a buffer, see SubscribeBuffer,
+// the handler is called on the goroutine running the command, in the order the handlers were subscribed.
+// A handler must not call Subscribe or Unsubscribe.
+func (p *Provider) Subscribe(handler EventHandler, opts ...SubscribeOption) *Subscription {
+	s := &Subscription{handler: handler}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.start()
+	p.events.lck.Lock()
+	p.events.subscriptions = append(p.events.subscriptions, s)
+	p.events.lck.Unlock()
+	return s
+}
+
+// Unsubscribe removes the subscription from the provider, it returns once all the events
+// queued for the subscription have been handled.
+func (p *Provider) Unsubscribe(s *Subscription) {
+	if s == nil {
+		return
+	}
+	p.events.lck.Lock()
+	found := false
+	for i := range p.events.subscriptions {
+		if p.events.subscriptions[i] == s {
+			p.events.subscriptions = append(p.events.subscriptions[:i], p.events.subscriptions[i+1:]...)
+			found = true
+			break
+		}
+	}
+	p.events.lck.Unlock()
+	if found {
+		s.stop()
+	}
+}
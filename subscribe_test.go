@@ -0,0 +1,59 @@
+package goose
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/00002_b.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+
+	// summaries returns a handler that records the commands of the summary events
+	summaries := func(commands *[]string) EventHandler {
+		return func(e Eventer) {
+			if summary, ok := e.(CommandSummaryEvent); ok {
+				*commands = append(*commands, summary.Command)
+			}
+		}
+	}
+	var direct, buffered []string
+	directSub := p.Subscribe(summaries(&direct))
+	bufferedSub := p.Subscribe(summaries(&buffered), SubscribeBuffer(2))
+
+	// a handler that blocks until released, with no room in its buffer, drops every other event
+	release := make(chan struct{})
+	blocked := p.Subscribe(func(Eventer) { <-release }, SubscribeDropWhenFull())
+
+	if err := p.Up(db, "migrations", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Down(db, "migrations", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	p.Unsubscribe(directSub)
+	if err := p.Redo(db, "migrations", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	p.Unsubscribe(bufferedSub)
+
+	if blocked.Dropped() == 0 {
+		t.Errorf("dropped, expected the blocked subscription to drop events")
+	}
+	close(release)
+	p.Unsubscribe(blocked)
+
+	expectedDirect := []string{"up", "down"}
+	expectedBuffered := []string{"up", "down", "redo"}
+	if !equalStrings(direct, expectedDirect) {
+		t.Errorf("direct subscription, got %v expected %v", direct, expectedDirect)
+	}
+	if !equalStrings(buffered, expectedBuffered) {
+		t.Errorf("buffered subscription, got %v expected %v", buffered, expectedBuffered)
+	}
+}
@@ -0,0 +1,257 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TableExistsDialect is implemented by the dialects that can ask the database if a table, or a column of the
+// version table, exists, all the dialects of goose but the GenericDialect do. TableExistsSQL returns a query of
+// the number of tables named as the version table with the suffix, in its schema, so 0 if the table does not
+// exist; and ColumnExistsSQL a query of the number of columns of the version table named column.
+type TableExistsDialect interface {
+	TableExistsSQL(suffix string) string
+	ColumnExistsSQL(column string) string
+}
+
+// MissingTableDialect is implemented by the dialects that can not ask the database if a table exists, see
+// TableExistsDialect, but tell the error of a query on a table that does not exist apart from the other
+// errors. For the dialects that implement neither the error messages of the drivers of the built in
+// dialects are recognised.
+type MissingTableDialect interface {
+	IsMissingTable(err error) bool
+}
+
+// missingTableMessages are parts of the messages the drivers report a table that does not exist with
+var missingTableMessages = []string{
+	"no such table",       // sqlite
+	"does not exist",      // postgres, redshift
+	"doesn't exist",       // mysql, tidb, clickhouse
+	"invalid object name", // sql server
+	"unknown_table",       // clickhouse
+}
+
+// missingColumnMessages are parts of the messages the drivers report a column that does not exist with
+var missingColumnMessages = []string{
+	"no such column",      // sqlite
+	"does not exist",      // postgres, redshift
+	"unknown column",      // mysql, tidb
+	"invalid column name", // sql server
+	"missing columns",     // clickhouse
+	"unknown identifier",  // clickhouse
+}
+
+// errTableMissing is the error of a table that does not exist, when the dialect told it does not
+var errTableMissing = errors.New("table does not exist")
+
+// tableExists returns if the table named as the version table with the suffix exists, the tables of goose
+// other than the version table are only created when they are needed. For the dialects that do not
+// implement TableExistsDialect the table is queried with query, and the error it fails with is checked.
+func (p *Provider) tableExists(db *sql.DB, suffix string, query func() (*sql.Rows, error)) (bool, error) {
+	if td, ok := p.dialect.(TableExistsDialect); ok {
+		var count int64
+		if err := db.QueryRow(td.TableExistsSQL(suffix)).Scan(&count); err != nil {
+			return false, fmt.Errorf("failed to check if table %s%s exists: %w", p.tableName, suffix, err)
+		}
+		return count > 0, nil
+	}
+	rows, err := query()
+	if err == nil {
+		return true, rows.Close()
+	}
+	if p.isMissingTable(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// columnExists returns if the version table has the column. For the dialects that do not implement
+// TableExistsDialect the column is queried with query, and the error it fails with is checked.
+func (p *Provider) columnExists(db *sql.DB, column string, query func() (*sql.Rows, error)) (bool, error) {
+	if td, ok := p.dialect.(TableExistsDialect); ok {
+		var count int64
+		if err := db.QueryRow(td.ColumnExistsSQL(column)).Scan(&count); err != nil {
+			return false, fmt.Errorf("failed to check if column %s of table %s exists: %w", column, p.tableName, err)
+		}
+		return count > 0, nil
+	}
+	rows, err := query()
+	if err == nil {
+		return true, rows.Close()
+	}
+	if hasMessage(err, missingColumnMessages) {
+		return false, nil
+	}
+	return false, err
+}
+
+// isMissingTable returns if err is the error of a query on a table that does not exist
+func (p *Provider) isMissingTable(err error) bool {
+	if md, ok := p.dialect.(MissingTableDialect); ok {
+		return md.IsMissingTable(err)
+	}
+	return hasMessage(err, missingTableMessages)
+}
+
+// hasMessage returns if the message of err contains any of the parts, ignoring case
+func hasMessage(err error, parts []string) bool {
+	msg := strings.ToLower(err.Error())
+	for _, part := range parts {
+		if strings.Contains(msg, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// storedNames returns the schema and the name of the version table, with the suffix, without their quotes,
+// as the database stores them. The names not written quoted are folded with fold, if it is not nil, unless
+// QuoteIdentifiers is set.
+func (bd BaseDialect) storedNames(open, close, suffix string, fold func(string) string) (string, string) {
+	stored := func(name, suffix string) string {
+		if unquoted, ok := UnquoteIdent(open, close, name); ok {
+			return unquoted + suffix
+		}
+		if fold != nil && !bd.QuoteIdentifiers {
+			return fold(name + suffix)
+		}
+		return name + suffix
+	}
+	schema, table := bd.SchemaAndTable()
+	if schema != "" {
+		schema = stored(schema, "")
+	}
+	return schema, stored(table, suffix)
+}
+
+// quoteString returns s as a string literal, backslashes are escaped for the databases that treat
+// them as escape characters in string literals.
+func quoteString(s string, backslash bool) string {
+	if backslash {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// informationSchemaSQL returns the query of the number of tables named table in schema, or in the schema
+// returned by the current function if schema is empty, from information_schema.tables. If column is set
+// it is the number of columns of the table named column, from information_schema.columns.
+func informationSchemaSQL(schema, table, column, current string, backslash bool) string {
+	if schema != "" {
+		current = quoteString(schema, backslash)
+	}
+	if column != "" {
+		return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = %s AND table_name = %s AND column_name = %s",
+			current, quoteString(table, backslash), quoteString(column, backslash))
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = %s AND table_name = %s",
+		current, quoteString(table, backslash))
+}
+
+func (d PostgresDialect) TableExistsSQL(suffix string) string {
+	schema, table := d.storedNames(`"`, `"`, suffix, strings.ToLower)
+	return informationSchemaSQL(schema, table, "", "current_schema()", false)
+}
+
+func (d PostgresDialect) ColumnExistsSQL(column string) string {
+	schema, table := d.storedNames(`"`, `"`, "", strings.ToLower)
+	return informationSchemaSQL(schema, table, column, "current_schema()", false)
+}
+
+func (d RedshiftDialect) TableExistsSQL(suffix string) string {
+	schema, table := d.storedNames(`"`, `"`, suffix, strings.ToLower)
+	return informationSchemaSQL(schema, table, "", "current_schema()", false)
+}
+
+func (d RedshiftDialect) ColumnExistsSQL(column string) string {
+	schema, table := d.storedNames(`"`, `"`, "", strings.ToLower)
+	return informationSchemaSQL(schema, table, column, "current_schema()", false)
+}
+
+func (d MySQLDialect) TableExistsSQL(suffix string) string {
+	schema, table := d.storedNames("`", "`", suffix, nil)
+	return informationSchemaSQL(schema, table, "", "DATABASE()", true)
+}
+
+func (d MySQLDialect) ColumnExistsSQL(column string) string {
+	schema, table := d.storedNames("`", "`", "", nil)
+	return informationSchemaSQL(schema, table, column, "DATABASE()", true)
+}
+
+func (d TiDBDialect) TableExistsSQL(suffix string) string {
+	schema, table := d.storedNames("`", "`", suffix, nil)
+	return informationSchemaSQL(schema, table, "", "DATABASE()", true)
+}
+
+func (d TiDBDialect) ColumnExistsSQL(column string) string {
+	schema, table := d.storedNames("`", "`", "", nil)
+	return informationSchemaSQL(schema, table, column, "DATABASE()", true)
+}
+
+func (d SqlServerDialect) TableExistsSQL(suffix string) string {
+	schema, table := d.storedNames("[", "]", suffix, nil)
+	return informationSchemaSQL(schema, table, "", "SCHEMA_NAME()", false)
+}
+
+func (d SqlServerDialect) ColumnExistsSQL(column string) string {
+	schema, table := d.storedNames("[", "]", "", nil)
+	return informationSchemaSQL(schema, table, column, "SCHEMA_NAME()", false)
+}
+
+// sqliteSchema returns the schema of the version table, the attached database, as an argument of
+// a pragma function, which is main if it is not set
+func (d Sqlite3Dialect) sqliteSchema() string {
+	schema, _ := d.storedNames(`"`, `"`, "", nil)
+	if schema == "" {
+		schema = "main"
+	}
+	return quoteString(schema, false)
+}
+
+func (d Sqlite3Dialect) TableExistsSQL(suffix string) string {
+	// the schema of a sqlite table is the attached database, which has its own sqlite_master
+	master := "sqlite_master"
+	if schema, _ := d.SchemaAndTable(); schema != "" {
+		master = d.ident(`"`, `"`, schema, "") + ".sqlite_master"
+	}
+	_, table := d.storedNames(`"`, `"`, suffix, nil)
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE type = 'table' AND name = %s COLLATE NOCASE", master, quoteString(table, false))
+}
+
+func (d Sqlite3Dialect) ColumnExistsSQL(column string) string {
+	_, table := d.storedNames(`"`, `"`, "", nil)
+	return fmt.Sprintf("SELECT COUNT(*) FROM pragma_table_info(%s, %s) WHERE name = %s COLLATE NOCASE",
+		quoteString(table, false), d.sqliteSchema(), quoteString(column, false))
+}
+
+// clickHouseDatabase returns the database of the version table, the current database if it is not set
+func (d ClickHouseDialect) clickHouseDatabase() string {
+	schema, _ := d.storedNames("`", "`", "", nil)
+	if schema == "" {
+		return "currentDatabase()"
+	}
+	return quoteString(schema, true)
+}
+
+func (d ClickHouseDialect) TableExistsSQL(suffix string) string {
+	_, table := d.storedNames("`", "`", suffix, nil)
+	return fmt.Sprintf("SELECT COUNT(*) FROM system.tables WHERE database = %s AND name = %s", d.clickHouseDatabase(), quoteString(table, true))
+}
+
+func (d ClickHouseDialect) ColumnExistsSQL(column string) string {
+	_, table := d.storedNames("`", "`", "", nil)
+	return fmt.Sprintf("SELECT COUNT(*) FROM system.columns WHERE database = %s AND table = %s AND name = %s",
+		d.clickHouseDatabase(), quoteString(table, true), quoteString(column, true))
+}
+
+var (
+	_ TableExistsDialect = PostgresDialect{}
+	_ TableExistsDialect = MySQLDialect{}
+	_ TableExistsDialect = SqlServerDialect{}
+	_ TableExistsDialect = Sqlite3Dialect{}
+	_ TableExistsDialect = RedshiftDialect{}
+	_ TableExistsDialect = TiDBDialect{}
+	_ TableExistsDialect = ClickHouseDialect{}
+)
@@ -0,0 +1,210 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// tagsAnnotation declares the tags of a SQL migration, e.g. -- +goose Tags: dev,perf
+const tagsAnnotation = "+goose Tags:"
+
+// excludeTagPrefix marks an excluded tag in the recorded active tags, e.g. dev,!perf
+const excludeTagPrefix = "!"
+
+// parseTags splits the comma separated list of tags, dropping empty tags
+func parseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// hasAnyTag returns if any of the tags are in set
+func hasAnyTag(tags []string, set []string) bool {
+	for _, tag := range tags {
+		for _, s := range set {
+			if tag == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithTags only applies the tagged migrations that have at least one of the tags, migrations without
+// tags are always applied. Rolling back migrations is not filtered by tags.
+func WithTags(tags ...string) OptionsFunc {
+	return func(o *options) { o.tags = append(o.tags, tags...) }
+}
+
+// WithoutTags does not apply the migrations that have any of the tags.
+// Rolling back migrations is not filtered by tags.
+func WithoutTags(tags ...string) OptionsFunc {
+	return func(o *options) { o.withoutTags = append(o.withoutTags, tags...) }
+}
+
+// filtersTags returns if the command only applies some of the tagged migrations
+func (o *options) filtersTags() bool {
+	return o != nil && (len(o.tags) > 0 || len(o.withoutTags) > 0)
+}
+
+// skippedByTag returns if the migration is not applied because of its tags
+func (o *options) skippedByTag(m *Migration) bool {
+	if !o.filtersTags() || len(m.Tags) == 0 {
+		return false
+	}
+	if hasAnyTag(m.Tags, o.withoutTags) {
+		return true
+	}
+	return len(o.tags) > 0 && !hasAnyTag(m.Tags, o.tags)
+}
+
+// filterByTags returns the migrations that are not skipped by tag, connected to each other
+func (o *options) filterByTags(migrations Migrations) Migrations {
+	if !o.filtersTags() {
+		return migrations
+	}
+	filtered := make(Migrations, 0, len(migrations))
+	for _, m := range migrations {
+		if !o.skippedByTag(m) {
+			filtered = append(filtered, m)
+		}
+	}
+	// the versions were unique before filtering, so connecting them can not fail
+	filtered, _ = sortAndConnectMigrations(filtered)
+	return filtered
+}
+
+// activeTags returns the tags the command is run with as they are recorded, the excluded tags
+// are prefixed with a !.
+func (o *options) activeTags() string {
+	tags := make([]string, 0, len(o.tags)+len(o.withoutTags))
+	tags = append(tags, o.tags...)
+	for _, tag := range o.withoutTags {
+		tags = append(tags, excludeTagPrefix+tag)
+	}
+	return strings.Join(tags, ",")
+}
+
+// parseActiveTags returns the options of the recorded active tags, see activeTags
+func parseActiveTags(s string) *options {
+	o := &options{}
+	for _, tag := range parseTags(s) {
+		if strings.HasPrefix(tag, excludeTagPrefix) {
+			o.withoutTags = append(o.withoutTags, strings.TrimPrefix(tag, excludeTagPrefix))
+			continue
+		}
+		o.tags = append(o.tags, tag)
+	}
+	return o
+}
+
+// ensureTagsColumn returns if the version table has the column the active tags are recorded in, adding it if
+// add is set. Version tables created before the tags were recorded do not have it.
+func (p *Provider) ensureTagsColumn(db *sql.DB, add bool) (bool, error) {
+	exists, err := p.columnExists(db, "tags", func() (*sql.Rows, error) { return db.Query(p.dialect.TagsQuerySQL()) })
+	if err != nil {
+		return false, fmt.Errorf("failed to query goose tags: %w", err)
+	}
+	if exists || !add {
+		return exists, nil
+	}
+	if _, err := db.Exec(p.dialect.AddTagsColumnSQL()); err != nil {
+		return false, fmt.Errorf("failed to add the tags column: %w", err)
+	}
+	return true, nil
+}
+
+// tagSkippedVersions returns the versions of the migrations not applied because a command that applied a
+// later version skipped them by tag, as the tags it was run with are recorded. They are not missing
+// migrations, up applies them without WithAllowMissing.
+func (p *Provider) tagSkippedVersions(db *sql.DB, migrations, dbMigrations Migrations) (map[int64]bool, error) {
+	applied := make(map[int64]bool, len(dbMigrations))
+	for _, m := range dbMigrations {
+		applied[m.Version] = true
+	}
+	var pending Migrations
+	for _, m := range migrations {
+		if !applied[m.Version] && len(m.Tags) > 0 {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	if exists, err := p.ensureTagsColumn(db, false); err != nil || !exists {
+		return nil, err
+	}
+	rows, err := db.Query(p.dialect.TagsQuerySQL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goose tags: %w", err)
+	}
+	defer rows.Close()
+	skipped := make(map[int64]bool)
+	for rows.Next() {
+		var (
+			version int64
+			tags    string
+		)
+		if err := rows.Scan(&version, &tags); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		active := parseActiveTags(tags)
+		for _, m := range pending {
+			if m.Version < version && active.skippedByTag(m) {
+				skipped[m.Version] = true
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get next row: %w", err)
+	}
+	return skipped, nil
+}
+
+// AddMigrationWithTags adds a migration with the given tags, see WithTags. It will panic if the migration
+// can not be added, use AddMigrationWithTagsE to get an error instead.
+func AddMigrationWithTags(up func(*sql.Tx) error, down func(*sql.Tx) error, tags ...string) {
+	_, filename, _, _ := runtime.Caller(1)
+	defaultProvider.AddNamedMigrationWithTags(filename, up, down, tags...)
+}
+
+// AddMigrationWithTags adds a migration with the given tags, see WithTags. It will panic if the migration
+// can not be added, use AddMigrationWithTagsE to get an error instead.
+func (p *Provider) AddMigrationWithTags(up func(*sql.Tx) error, down func(*sql.Tx) error, tags ...string) {
+	_, filename, _, _ := runtime.Caller(1)
+	p.AddNamedMigrationWithTags(filename, up, down, tags...)
+}
+
+// AddMigrationWithTagsE adds a migration with the given tags, returning an error if the migration can
+// not be added.
+func AddMigrationWithTagsE(up func(*sql.Tx) error, down func(*sql.Tx) error, tags ...string) error {
+	_, filename, _, _ := runtime.Caller(1)
+	return defaultProvider.AddNamedMigrationWithTagsE(filename, up, down, tags...)
+}
+
+// AddMigrationWithTagsE adds a migration with the given tags, returning an error if the migration can
+// not be added.
+func (p *Provider) AddMigrationWithTagsE(up func(*sql.Tx) error, down func(*sql.Tx) error, tags ...string) error {
+	_, filename, _, _ := runtime.Caller(1)
+	return p.AddNamedMigrationWithTagsE(filename, up, down, tags...)
+}
+
+// AddNamedMigrationWithTags adds a named migration with the given tags. It will panic if the migration
+// can not be added, use AddNamedMigrationWithTagsE to get an error instead.
+func (p *Provider) AddNamedMigrationWithTags(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error, tags ...string) {
+	if err := p.AddNamedMigrationWithTagsE(filename, up, down, tags...); err != nil {
+		panic(err.Error())
+	}
+}
+
+// AddNamedMigrationWithTagsE adds a named migration with the given tags, returning an error if the
+// migration can not be added, see AddNamedMigrationE.
+func (p *Provider) AddNamedMigrationWithTagsE(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error, tags ...string) error {
+	return p.AddNamedMigrationWithOptionsE(filename, up, down, MigrationTags(tags...))
+}
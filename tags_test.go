@@ -0,0 +1,189 @@
+package goose
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/00002_b.sql": {Data: []byte("-- +goose Tags: dev\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+		"migrations/00003_c.sql": {Data: []byte("-- +goose Tags: perf, dev\n-- +goose Up\nCREATE TABLE c (id INTEGER);\n-- +goose Down\nDROP TABLE c;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+	noop := func(*sql.Tx) error { return nil }
+	if err := p.AddNamedMigrationWithTagsE("00004_d.go", noop, noop, "dev"); err != nil {
+		t.Fatal(err)
+	}
+
+	// up runs Up with the options, returning the versions applied
+	up := func(t *testing.T, opts ...OptionsFunc) []int64 {
+		var versions []int64
+		sub := p.Subscribe(func(e Eventer) {
+			if apply, ok := e.(VersionApplyEvent); ok && apply.Applied {
+				versions = append(versions, apply.To)
+			}
+		})
+		defer p.Unsubscribe(sub)
+		if err := p.Up(db, "migrations", append(opts, WithNoOutput())...); err != nil {
+			t.Fatal(err)
+		}
+		return versions
+	}
+	t.Run("with tags", func(t *testing.T) {
+		expected := []int64{1, 3}
+		if got := up(t, WithTags("perf")); !equalVersions(got, expected) {
+			t.Errorf("applied, got %v expected %v", got, expected)
+		}
+	})
+	t.Run("status", func(t *testing.T) {
+		var got []StatusEvent
+		sub := p.Subscribe(func(e Eventer) {
+			if se, ok := e.(StatusEvent); ok {
+				got = append(got, se)
+			}
+		})
+		err := p.Status(db, "migrations", WithTags("perf"), WithNoOutput())
+		p.Unsubscribe(sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		expected := []StatusEvent{
+			{Source: "migrations/00001_a.sql", Version: 1, Versioned: true, AppliedAt: now},
+			{Source: "migrations/00002_b.sql", Version: 2, Versioned: true, Tags: []string{"dev"}, Skipped: true},
+			{Source: "migrations/00003_c.sql", Version: 3, Versioned: true, AppliedAt: now, Tags: []string{"perf", "dev"}},
+			{Source: "00004_d.go", Version: 4, Versioned: true, Tags: []string{"dev"}, Skipped: true},
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("status, got %v expected %v", got, expected)
+		}
+		for i := range expected {
+			if !expected[i].IsEqual(got[i]) {
+				t.Errorf("status %d, got %+v expected %+v", i, got[i], expected[i])
+			}
+		}
+		if got[1].AppliedString() != skippedVersion {
+			t.Errorf("applied string, got %q expected %q", got[1].AppliedString(), skippedVersion)
+		}
+	})
+	t.Run("skipped are not missing", func(t *testing.T) {
+		if got := up(t, WithTags("perf")); len(got) != 0 {
+			t.Errorf("applied, got %v expected none", got)
+		}
+	})
+	t.Run("without tags", func(t *testing.T) {
+		// version 2 was skipped by tag when version 3 was applied, it is not missing
+		expected := []int64{2, 4}
+		if got := up(t, WithoutTags("perf")); !equalVersions(got, expected) {
+			t.Errorf("applied, got %v expected %v", got, expected)
+		}
+	})
+	t.Run("recorded tags", func(t *testing.T) {
+		rows, err := db.Query("SELECT version_id, tags FROM goose_db_version WHERE tags IS NOT NULL ORDER BY version_id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		expected := map[int64]string{1: "perf", 2: "!perf", 3: "perf", 4: "!perf"}
+		got := map[int64]string{}
+		for rows.Next() {
+			var (
+				version int64
+				tags    string
+			)
+			if err := rows.Scan(&version, &tags); err != nil {
+				t.Fatal(err)
+			}
+			got[version] = tags
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("recorded tags, got %v expected %v", got, expected)
+		}
+		for v, tags := range expected {
+			if got[v] != tags {
+				t.Errorf("recorded tags of %d, got %q expected %q", v, got[v], tags)
+			}
+		}
+	})
+}
+
+func TestTagSkippedVersions(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/00003_c.sql": {Data: []byte("-- +goose Tags: dev\n-- +goose Up\nCREATE TABLE c (id INTEGER);\n-- +goose Down\nDROP TABLE c;\n")},
+		"migrations/00005_e.sql": {Data: []byte("-- +goose Up\nCREATE TABLE e (id INTEGER);\n-- +goose Down\nDROP TABLE e;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+
+	if err := p.Up(db, "migrations", WithoutTags("dev"), WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	// a migration added below the applied versions is missing, the one skipped by tag is not
+	fsys["migrations/00002_b.sql"] = &fstest.MapFile{Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")}
+	err := p.Up(db, "migrations", WithNoOutput())
+	var missing MissingMigrationsErr
+	if !errors.As(err, &missing) || len(missing.MissingMigrations) != 1 || missing.MissingMigrations[0].Version != 2 {
+		t.Fatalf("error, got %v expected only version 2 to be missing", err)
+	}
+	if err := p.Up(db, "migrations", WithAllowMissing(), WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("SELECT id FROM c"); err != nil {
+		t.Errorf("table c: %v", err)
+	}
+}
+
+func TestTagsColumnAdded(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"migrations/00002_b.sql": {Data: []byte("-- +goose Tags: dev\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+	}
+	p, db := newSQLiteProvider(t, fsys)
+	// a version table created before the tags were recorded
+	for _, query := range []string{
+		"CREATE TABLE goose_db_version (id INTEGER PRIMARY KEY AUTOINCREMENT, version_id INTEGER NOT NULL, is_applied INTEGER NOT NULL, tstamp TIMESTAMP DEFAULT (datetime('now')))",
+		"INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, 1)",
+	} {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hasColumn := func() bool {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('goose_db_version') WHERE name = 'tags'").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count > 0
+	}
+
+	if err := p.UpByOne(db, "migrations", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	if hasColumn() {
+		t.Errorf("tags column, got it added without tags expected it not to be")
+	}
+	if err := p.Up(db, "migrations", WithTags("dev"), WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	if !hasColumn() {
+		t.Fatalf("tags column, got it missing expected it to be added")
+	}
+	var (
+		version int64
+		tags    string
+	)
+	if err := db.QueryRow("SELECT version_id, tags FROM goose_db_version WHERE tags IS NOT NULL").Scan(&version, &tags); err != nil || version != 2 || tags != "dev" {
+		t.Errorf("recorded tags, got %d %q, %v expected 2 \"dev\"", version, tags, err)
+	}
+}
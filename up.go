@@ -14,24 +14,103 @@ type options struct {
 	applyUpByOne     bool
 	noVersioning     bool
 	noOutput         bool
+	dryRun           bool
 	eventsChannel    chan<- Eventer
 	dontCloseChannel bool
 	// sequentialVersionsOnly will only allow up to apply if only sequential version files exist
 	sequentialVersionsOnly bool
+	// bus is the provider's event bus, the events are published to it as well as the eventsChannel
+	bus *eventBus
+	// tags and withoutTags filter the tagged migrations applied, see WithTags and WithoutTags
+	tags, withoutTags []string
+	// target is the name of the FanOut target the command is run against, the events are wrapped in a TargetEvent
+	target string
+	// readOnly is set if the command must not change the database, see WithReadOnly
+	readOnly bool
+	// history is set if the migrations run are recorded in the history, see WithHistory
+	history bool
+	// appliedBy is who the migrations are recorded as run by in the history
+	appliedBy string
 }
 
-// send will sent the event over the eventsChannel if it is not nil
-func (o options) send(e Eventer) {
+// runState is the state of a command while it runs, next to the options it was run with. A new one is
+// created for each command, so the options are never changed by the command that runs with them.
+type runState struct {
+	*options
+	// callbacks are the SQL callbacks found in the migrations directory
+	callbacks sqlCallbacks
+	// beforeAllRan is set once the BeforeAll hooks have been run by the command
+	beforeAllRan bool
+	// applied and lastVersion track the migrations applied by the command, for the CommandSummaryEvent
+	applied     int
+	lastVersion int64
+	// countSent is set once the VersionCountEvent of the command has been sent
+	countSent bool
+	// recordTags is set if the tags active are recorded in the version rows of the migrations applied
+	recordTags bool
+	// historyChecked is set once the history table has been checked for by the command, and recordHistory
+	// if the history is recorded
+	historyChecked bool
+	recordHistory  bool
+	// migrationStart is the time the migration being run was started at
+	migrationStart time.Time
+}
+
+// newRun returns the state of a command run on the provider with the options.
+func (p *Provider) newRun(opts []OptionsFunc) *runState {
+	return &runState{options: p.applyOptions(opts), lastVersion: -1}
+}
+
+// send will publish the event to the provider's subscribers, and sent it over the eventsChannel if it is not nil.
+// The event is wrapped in a TargetEvent if the command is run against a FanOut target.
+func (o *options) send(e Eventer) {
+	if o == nil {
+		return
+	}
+	if o.target != "" {
+		e = TargetEvent{Target: o.target, Wrapped: e}
+	}
+	o.bus.publish(e)
 	if o.eventsChannel == nil {
 		return
 	}
 	o.eventsChannel <- e
 }
 
+// send sends the event of the command, see options.send. A run may be nil.
+func (r *runState) send(e Eventer) {
+	if r == nil {
+		return
+	}
+	if _, ok := e.(VersionCountEvent); ok {
+		r.countSent = true
+	}
+	r.options.send(e)
+}
+
 func (o options) shouldCloseEventsChannel() bool {
 	return o.eventsChannel != nil && !o.dontCloseChannel
 }
 
+// sendSummary sends the CommandSummaryEvent for the command, err is the error the command is returning.
+// A command that stopped before it counted the migrations to apply sends a VersionCountEvent with none
+// left first, so every command starts with one.
+func (r *runState) sendSummary(command string, down bool, err error) {
+	if r == nil {
+		return
+	}
+	if !r.countSent {
+		r.send(VersionCountEvent{Version: -1})
+	}
+	r.send(CommandSummaryEvent{
+		Command: command,
+		Down:    down,
+		Applied: r.applied,
+		Version: r.lastVersion,
+		Err:     err,
+	})
+}
+
 type OptionsFunc func(o *options)
 
 func WithAllowMissing() OptionsFunc {
@@ -61,30 +140,53 @@ func WithEvents(events chan<- Eventer, DontCloseChannelOnComplete bool) OptionsF
 	}
 }
 
-// withDontCloseChannel will prevent the Event channel from being closed after the
-// function exits
-func withDontCloseChannel() OptionsFunc {
-	return func(o *options) { o.dontCloseChannel = true }
-}
-
 // WithNoOutput will suppress the output of the function
 func WithNoOutput() OptionsFunc {
 	return func(o *options) { o.noOutput = true }
 }
 
+// WithDryRun will report what the function would do, without making any changes.
+// Currently only supported by Fix and PruneOrphans.
+func WithDryRun() OptionsFunc {
+	return func(o *options) { o.dryRun = true }
+}
+
+// WithReadOnly will not let the function change the database. Status and Version do not create the version
+// table, and report ErrNotInitialized if it does not exist; the functions applying or rolling back migrations
+// return ErrReadOnly.
+func WithReadOnly() OptionsFunc {
+	return func(o *options) { o.readOnly = true }
+}
+
+// checkReadOnly returns an ErrReadOnly for the command if it is run read-only
+func (o *options) checkReadOnly(command string) error {
+	if o.readOnly {
+		return ErrReadOnly{Command: command}
+	}
+	return nil
+}
+
 func applyOptions(opts []OptionsFunc) *options {
-	option := new(options)
+	option := &options{}
 	for _, f := range opts {
 		f(option)
 	}
 	return option
 }
 
+// applyOptions applies the options for a command run on the provider, the events of the
+// command are published to the provider's subscribers.
+func (p *Provider) applyOptions(opts []OptionsFunc) *options {
+	option := applyOptions(opts)
+	option.bus = p.events
+	return option
+}
+
 type VersionCountEvent struct {
-	*Event
-	Version           int64
-	VersionSource     string
-	TotalVersionsLeft int
+	*Event            `json:"-"`
+	Version           int64  `json:"version"`
+	VersionSource     string `json:"version_source"`
+	TotalVersionsLeft int    `json:"total_versions_left"`
 }
 
 func (e VersionCountEvent) IsEqual(o Eventer) bool {
@@ -109,16 +211,16 @@ var (
 // VersionApplyEvent usually comes in pairs unless there is an error, the first event (with applied set to false) will be emmited
 // before the version is applied to the database, and the applied version after the new version has been applied.
 type VersionApplyEvent struct {
-	*Event
-	From       int64
-	FromSource string
-	To         int64
-	ToSource   string
-	ApplyAT    time.Time
-	Missing    bool
-	Applied    bool
-	Versioned  bool
-	Down       bool
+	*Event     `json:"-"`
+	From       int64     `json:"from"`
+	FromSource string    `json:"from_source"`
+	To         int64     `json:"to"`
+	ToSource   string    `json:"to_source"`
+	ApplyAT    time.Time `json:"apply_at"`
+	Missing    bool      `json:"missing"`
+	Applied    bool      `json:"applied"`
+	Versioned  bool      `json:"versioned"`
+	Down       bool      `json:"down"`
 }
 
 func (e VersionApplyEvent) IsEqual(o Eventer) bool {
@@ -145,20 +247,118 @@ var (
 	_ = Eventer(VersionApplyEvent{})
 )
 
+// applyMigration runs the migration in the direction of the apply event, running the BeforeAll hooks first if
+// it is the first migration of the command. The apply event is sent, with Applied
+// set to false, before the migration is run, and again with Applied set to true after it has been applied. If the
+// migration fails a VersionFailedEvent is sent instead, a failing afterEachMigrate callback does not make the
+// committed migration fail, its ErrHook is returned once the migration is counted as applied.
+func (p *Provider) applyMigration(db *sql.DB, m *Migration, apply VersionApplyEvent, option *runState) error {
+	if err := p.runBeforeAll(db, !apply.Down, option); err != nil {
+		return err
+	}
+	if option != nil && option.history && !option.historyChecked {
+		option.historyChecked = true
+		var err error
+		if option.recordHistory, err = p.ensureHistoryTable(db); err != nil {
+			return err
+		}
+	}
+	apply.ApplyAT = time.Now()
+	apply.Applied = false
+	if option != nil {
+		option.migrationStart = apply.ApplyAT
+	}
+	option.send(apply)
+	err := p.runWithCallbacks(db, m, !apply.Down, option)
+	var hookErr ErrHook
+	if err != nil && !(errors.As(err, &hookErr) && hookErr.Committed) {
+		option.send(VersionFailedEvent{
+			From:       apply.From,
+			FromSource: apply.FromSource,
+			To:         apply.To,
+			ToSource:   apply.ToSource,
+			FailedAt:   time.Now(),
+			Missing:    apply.Missing,
+			Versioned:  apply.Versioned,
+			Down:       apply.Down,
+			Err:        err,
+		})
+		return err
+	}
+	apply.ApplyAT = time.Now()
+	apply.Applied = true
+	option.send(apply)
+	if option != nil {
+		option.applied++
+		option.lastVersion = apply.To
+	}
+	return err
+}
+
+// runBeforeAll runs the BeforeAll hooks, and the beforeMigrate.sql callback, if they have not been run by the command yet
+func (p *Provider) runBeforeAll(db *sql.DB, direction bool, option *runState) error {
+	if option == nil || option.beforeAllRan {
+		return nil
+	}
+	option.beforeAllRan = true
+	if err := runHooks(hookBeforeAll, p.beforeAll, HookContext{Direction: directionOf(direction), DB: db}); err != nil {
+		return err
+	}
+	return p.runSQLCallback(CallbackBeforeMigrate, option.callbacks, direction, db, nil)
+}
+
+// runWithCallbacks runs the migration, with the each migration SQL callbacks around it if they are not
+// run in the migration's transaction. If the afterEachMigrate callback fails once the migration is committed
+// an ErrHook with Committed set is returned.
+func (p *Provider) runWithCallbacks(db *sql.DB, m *Migration, direction bool, option *runState) error {
+	if p.sqlCallbacksInTx || option == nil {
+		return m.run(p, db, direction, option)
+	}
+	if err := p.runSQLCallback(CallbackBeforeEachMigrate, option.callbacks, direction, db, nil); err != nil {
+		return m.migrationError(direction, err)
+	}
+	if err := m.run(p, db, direction, option); err != nil {
+		return err
+	}
+	// the migration is committed, a failing callback does not make it fail
+	if err := p.runSQLCallback(CallbackAfterEachMigrate, option.callbacks, direction, db, nil); err != nil {
+		hookErr := ErrHook{Hook: CallbackAfterEachMigrate, ErrUnwrap: ErrUnwrap{err}}
+		errors.As(err, &hookErr)
+		hookErr.Committed = true
+		hookErr.Source = m.Source
+		return hookErr
+	}
+	return nil
+}
+
 // UpTo migrates up to a specific version.
 func UpTo(db *sql.DB, dir string, version int64, opts ...OptionsFunc) error {
 	return defaultProvider.UpTo(db, dir, version, opts...)
 }
 
 func (p *Provider) UpTo(db *sql.DB, dir string, version int64, opts ...OptionsFunc) (err error) {
-	options := applyOptions(opts)
+	options := p.newRun(opts)
 	if options.shouldCloseEventsChannel() {
 		defer close(options.eventsChannel)
 	}
+	command := "up-to"
+	switch {
+	case options.applyUpByOne:
+		command = "up-by-one"
+	case version == maxVersion:
+		command = "up"
+	}
+	if err := options.checkReadOnly(command); err != nil {
+		return err
+	}
+	defer func() { err = p.finishCommand(db, options, command, false, err) }()
 	foundMigrations, err := p.CollectMigrations(dir, minVersion, version)
 	if err != nil {
 		return err
 	}
+	if options.callbacks, err = p.collectSQLCallbacks(p.baseFS, dir); err != nil {
+		return err
+	}
 
 	if options.sequentialVersionsOnly {
 		tsVers, _ := foundMigrations.timestamped()
@@ -171,7 +371,15 @@ func (p *Provider) UpTo(db *sql.DB, dir string, version int64, opts ...OptionsFu
 		}
 	}
 
+	foundMigrations = options.filterByTags(foundMigrations)
+	graph := foundMigrations.hasRequires()
+
 	if options.noVersioning {
+		if graph {
+			if foundMigrations, err = topologicalOrder(foundMigrations, nil); err != nil {
+				return err
+			}
+		}
 		totalMigrations := len(foundMigrations)
 		if totalMigrations == 0 {
 			options.send(VersionCountEvent{
@@ -205,13 +413,27 @@ func (p *Provider) UpTo(db *sql.DB, dir string, version int64, opts ...OptionsFu
 	if _, err := p.EnsureDBVersion(db); err != nil {
 		return err
 	}
+	if options.recordTags, err = p.ensureTagsColumn(db, options.filtersTags()); err != nil {
+		return err
+	}
 	//dbMigrations, err := listAllDBVersions(p.dialect, db)
 	dbMigrations, err := listAllDBVersions(p.dialect, db)
 	if err != nil {
 		return err
 	}
 
-	missingMigrations := findMissingMigrations(dbMigrations, foundMigrations)
+	if graph {
+		if err := p.upGraph(db, foundMigrations, dbMigrations, options); err != nil || version != maxVersion {
+			return err
+		}
+		return p.upRepeatable(db, dir, options)
+	}
+
+	tagSkipped, err := p.tagSkippedVersions(db, foundMigrations, dbMigrations)
+	if err != nil {
+		return err
+	}
+	missingMigrations := findMissingMigrations(dbMigrations, foundMigrations, tagSkipped)
 
 	// feature(mf): It is very possible someone may want to apply ONLY new migrations
 	// and skip missing migrations altogether. At the moment this is not supported,
@@ -220,14 +442,26 @@ func (p *Provider) UpTo(db *sql.DB, dir string, version int64, opts ...OptionsFu
 		return MissingMigrationsErrFromMigrations(missingMigrations)
 	}
 
-	if options.allowMissing {
-		return p.upWithMissing(
+	// the migrations skipped by tag before are applied out of order, as the missing migrations are
+	if options.allowMissing || len(tagSkipped) > 0 {
+		for _, m := range foundMigrations {
+			if tagSkipped[m.Version] {
+				missingMigrations = append(missingMigrations, m)
+			}
+		}
+		sort.SliceStable(missingMigrations, func(i, j int) bool {
+			return missingMigrations[i].Version < missingMigrations[j].Version
+		})
+		if err := p.upWithMissing(
 			db,
 			missingMigrations,
 			foundMigrations,
 			dbMigrations,
 			options,
-		)
+		); err != nil || version != maxVersion {
+			return err
+		}
+		return p.upRepeatable(db, dir, options)
 	}
 
 	var current int64
@@ -249,7 +483,7 @@ func (p *Provider) UpTo(db *sql.DB, dir string, version int64, opts ...OptionsFu
 			options.send(VersionCountEvent{
 				Version:           cMigration.Version,
 				VersionSource:     cMigration.Source,
-				TotalVersionsLeft: foundMigrations.NumberOfMigrations(current, false),
+				TotalVersionsLeft: foundMigrations.NumberOfMigrations(cMigration.Version, false),
 			})
 		}
 		next, err := foundMigrations.Next(current)
@@ -259,27 +493,15 @@ func (p *Provider) UpTo(db *sql.DB, dir string, version int64, opts ...OptionsFu
 			}
 			return fmt.Errorf("failed to find next migration: %v", err)
 		}
-		options.send(VersionApplyEvent{
+		if err := p.applyMigration(db, next, VersionApplyEvent{
 			From:       cMigration.Version,
 			FromSource: cMigration.Source,
 			To:         next.Version,
 			ToSource:   next.Source,
-			ApplyAT:    time.Now(),
-			Applied:    false,
 			Versioned:  true,
-		})
-		if err := next.UpWithProvider(p, db); err != nil {
+		}, options); err != nil {
 			return err
 		}
-		options.send(VersionApplyEvent{
-			From:       cMigration.Version,
-			FromSource: cMigration.Source,
-			To:         next.Version,
-			ToSource:   next.Source,
-			ApplyAT:    time.Now(),
-			Applied:    true,
-			Versioned:  true,
-		})
 		if options.applyUpByOne {
 			return nil
 		}
@@ -294,12 +516,16 @@ func (p *Provider) UpTo(db *sql.DB, dir string, version int64, opts ...OptionsFu
 	if options.applyUpByOne {
 		return ErrNoNextVersion
 	}
-	return nil
+	if version != maxVersion {
+		return nil
+	}
+	// the repeatable migrations are applied once all the versioned migrations have been
+	return p.upRepeatable(db, dir, options)
 }
 
 // upToNoVersioning applies up migrations up to, and including, the
 // target version.
-func (p *Provider) upToNoVersioning(db *sql.DB, migrations Migrations, version int64, options *options) (int64, error) {
+func (p *Provider) upToNoVersioning(db *sql.DB, migrations Migrations, version int64, options *runState) (int64, error) {
 	var finalVersion int64
 	var cMigration = &Migration{
 		Version: -1,
@@ -310,25 +536,14 @@ func (p *Provider) upToNoVersioning(db *sql.DB, migrations Migrations, version i
 			break
 		}
 		current.noVersioning = true
-		options.send(VersionApplyEvent{
+		if err := p.applyMigration(db, current, VersionApplyEvent{
 			From:       cMigration.Version,
 			FromSource: cMigration.Source,
 			To:         current.Version,
 			ToSource:   current.Source,
-			ApplyAT:    time.Now(),
-			Applied:    false,
-		})
-		if err := current.UpWithProvider(p, db); err != nil {
+		}, options); err != nil {
 			return -1, err
 		}
-		options.send(VersionApplyEvent{
-			From:       cMigration.Version,
-			FromSource: cMigration.Source,
-			To:         current.Version,
-			ToSource:   current.Source,
-			ApplyAT:    time.Now(),
-			Applied:    true,
-		})
 		finalVersion = current.Version
 	}
 	return finalVersion, nil
@@ -339,7 +554,7 @@ func (p *Provider) upWithMissing(
 	missingMigrations Migrations,
 	foundMigrations Migrations,
 	dbMigrations Migrations,
-	option *options,
+	option *runState,
 ) error {
 	lookupApplied := make(map[int64]bool)
 	for _, found := range dbMigrations {
@@ -373,29 +588,16 @@ func (p *Provider) upWithMissing(
 
 	// Apply all missing migrations first.
 	for _, missing := range missingMigrations {
-		option.send(VersionApplyEvent{
+		if err := p.applyMigration(db, missing, VersionApplyEvent{
 			From:       cMigration.Version,
 			FromSource: cMigration.Source,
 			To:         missing.Version,
 			ToSource:   missing.Source,
-			ApplyAT:    time.Now(),
-			Applied:    false,
 			Missing:    true,
 			Versioned:  true,
-		})
-		if err := missing.UpWithProvider(p, db); err != nil {
+		}, option); err != nil {
 			return err
 		}
-		option.send(VersionApplyEvent{
-			From:       cMigration.Version,
-			FromSource: cMigration.Source,
-			To:         missing.Version,
-			ToSource:   missing.Source,
-			ApplyAT:    time.Now(),
-			Applied:    true,
-			Missing:    true,
-			Versioned:  true,
-		})
 		cMigration = *missing
 		// Apply one migration and return early.
 		if option.applyUpByOne {
@@ -430,27 +632,15 @@ func (p *Provider) upWithMissing(
 			cMigration = *found
 			continue
 		}
-		option.send(VersionApplyEvent{
+		if err := p.applyMigration(db, found, VersionApplyEvent{
 			From:       cMigration.Version,
 			FromSource: cMigration.Source,
 			To:         found.Version,
 			ToSource:   found.Source,
-			ApplyAT:    time.Now(),
-			Applied:    false,
 			Versioned:  true,
-		})
-		if err := found.UpWithProvider(p, db); err != nil {
+		}, option); err != nil {
 			return err
 		}
-		option.send(VersionApplyEvent{
-			From:       cMigration.Version,
-			FromSource: cMigration.Source,
-			To:         found.Version,
-			ToSource:   found.Source,
-			ApplyAT:    time.Now(),
-			Applied:    true,
-			Versioned:  true,
-		})
 		if option.applyUpByOne {
 			return nil
 		}
@@ -500,7 +690,7 @@ func (p *Provider) UpByOne(db *sql.DB, dir string, opts ...OptionsFunc) error {
 // listAllDBVersions returns a list of all migrations, ordered ascending.
 // TODO(mf): fairly cheap, but a nice-to-have is pagination support.
 func listAllDBVersions(dialect SQLDialect, db *sql.DB) (Migrations, error) {
-	rows, err := dialect.dbVersionQuery(db)
+	rows, err := dialect.DBVersionQuery(db)
 	if err != nil {
 		return nil, createVersionTable(dialect, db)
 	}
@@ -529,8 +719,8 @@ func listAllDBVersions(dialect SQLDialect, db *sql.DB) (Migrations, error) {
 
 // findMissingMigrations migrations returns all missing migrations.
 // A migration is considered missing if it has a version less than the
-// current known max version.
-func findMissingMigrations(knownMigrations, newMigrations Migrations) Migrations {
+// current known max version, and was not skipped by tag.
+func findMissingMigrations(knownMigrations, newMigrations Migrations, skippedByTag map[int64]bool) Migrations {
 	max := knownMigrations[len(knownMigrations)-1].Version
 	existing := make(map[int64]bool, len(knownMigrations))
 	for _, known := range knownMigrations {
@@ -538,7 +728,7 @@ func findMissingMigrations(knownMigrations, newMigrations Migrations) Migrations
 	}
 	var missing Migrations
 	for _, newMigration := range newMigrations {
-		if !existing[newMigration.Version] && newMigration.Version < max {
+		if !existing[newMigration.Version] && newMigration.Version < max && !skippedByTag[newMigration.Version] {
 			missing = append(missing, newMigration)
 		}
 	}
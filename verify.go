@@ -1,13 +1,21 @@
 package goose
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type VerifyStatus struct {
 	Status int
 	Error  error
+	// Findings are all the issues found, ordered by file and line
+	Findings []VerifyFinding
 }
 
 func (vs VerifyStatus) Ok() bool {
@@ -35,6 +43,67 @@ const (
 	VerifyStatusTplSql = VerifyStatusErr | (1 << iota)
 )
 
+// VerifySeverity is how serious a VerifyFinding is
+type VerifySeverity string
+
+const (
+	// VerifySeverityError is a problem that will stop the migrations from being applied
+	VerifySeverityError VerifySeverity = "error"
+	// VerifySeverityWarning is a problem that should be looked at, but will not stop the migrations
+	// from being applied
+	VerifySeverityWarning VerifySeverity = "warning"
+)
+
+// VerifyCode identifies the kind of problem a VerifyFinding is reporting
+type VerifyCode string
+
+const (
+	// VerifyCodeTimestampMigration is a timestamp-based migration
+	VerifyCodeTimestampMigration VerifyCode = "timestamp-migration"
+	// VerifyCodeTemplate is a .tpl.sql file that failed to load, parse or execute
+	VerifyCodeTemplate VerifyCode = "template-error"
+	// VerifyCodeParse is a SQL file that failed to parse for one of the directions
+	VerifyCodeParse VerifyCode = "parse-error"
+	// VerifyCodeDuplicateVersion is a migration that shares its version with another migration
+	VerifyCodeDuplicateVersion VerifyCode = "duplicate-version"
+	// VerifyCodeVersionGap is a sequential migration whose version does not follow the previous one
+	VerifyCodeVersionGap VerifyCode = "version-gap"
+	// VerifyCodeInvalidFilename is a file that looks like a migration, but does not have a valid version
+	VerifyCodeInvalidFilename VerifyCode = "invalid-filename"
+	// VerifyCodeUnregisteredGo is a .go file in the migration directory that has no registered migration
+	VerifyCodeUnregisteredGo VerifyCode = "unregistered-go"
+	// VerifyCodeEmptyUp is a migration with no Up statements or function
+	VerifyCodeEmptyUp VerifyCode = "empty-up"
+	// VerifyCodeUnknownDependency is a migration that requires a version there is no migration for
+	VerifyCodeUnknownDependency VerifyCode = "unknown-dependency"
+	// VerifyCodeDependencyCycle is a migration that is part of a cycle of migrations requiring each other
+	VerifyCodeDependencyCycle VerifyCode = "dependency-cycle"
+)
+
+// VerifyFinding is a single issue found by Verify
+type VerifyFinding struct {
+	File string `json:"file"`
+	// Line is the line in File the issue is at, zero if it's about the whole file
+	Line     int            `json:"line,omitempty"`
+	Severity VerifySeverity `json:"severity"`
+	Code     VerifyCode     `json:"code"`
+	Message  string         `json:"message"`
+	// Err is the underlying error, if there is one
+	Err error `json:"-"`
+}
+
+func (f VerifyFinding) Error() string {
+	var str strings.Builder
+	str.WriteString(f.File)
+	if f.Line > 0 {
+		fmt.Fprintf(&str, ":%d", f.Line)
+	}
+	fmt.Fprintf(&str, ": %s [%s] %s", f.Severity, f.Code, f.Message)
+	return str.String()
+}
+
+func (f VerifyFinding) Unwrap() error { return f.Err }
+
 // Verify will check the migration directory to see if there are any errors, or other issues.
 // It will return a VerifyStatus with any errors it found
 func Verify(dir string) VerifyStatus { return defaultProvider.Verify(dir) }
@@ -42,62 +111,310 @@ func Verify(dir string) VerifyStatus { return defaultProvider.Verify(dir) }
 // Verify will check the migration directory to see if there are any errors, or other issues.
 // It will return a VerifyStatus with any errors it found
 func (p *Provider) Verify(dir string) VerifyStatus {
-	if p.baseDir != "" && (dir == "" || dir == ".") {
-		dir = p.baseDir
-	}
-	migrations, err := p.collectMigrationsFS(p.baseFS, dir, minVersion, maxVersion)
-	if err != nil {
+	dir = p.BaseDir(dir)
+	if _, err := fs.Stat(p.baseFS, dir); err != nil {
 		return VerifyStatus{
 			Status: VerifyStatusErr,
 			Error:  fmt.Errorf("failed to get migrations: %w", err),
 		}
 	}
-	status := VerifyStatusOK
-	// split into timestamped and versioned migrations
-	tsMigrations, err := migrations.timestamped()
+	findings, err := p.verifyFindings(p.baseFS, dir)
 	if err != nil {
 		return VerifyStatus{
 			Status: VerifyStatusErr,
-			Error:  fmt.Errorf("failed to get timestamped migrations: %w", err),
+			Error:  fmt.Errorf("failed to get migrations: %w", err),
 		}
 	}
-	vMigrations, err := migrations.versioned()
-	if err != nil {
-		return VerifyStatus{
-			Status: VerifyStatusErr,
-			Error:  fmt.Errorf("failed to get migrations: %w", err),
+
+	status := VerifyStatusOK
+	var errs []error
+	for _, f := range findings {
+		switch f.Code {
+		case VerifyCodeTimestampMigration:
+			status |= VerifyStatusTsMigrations
+		case VerifyCodeTemplate:
+			status |= VerifyStatusTplSql
+		}
+		if f.Severity == VerifySeverityError {
+			status |= VerifyStatusErr
+			errs = append(errs, f)
 		}
 	}
-	if len(tsMigrations) > 0 {
-		status |= VerifyStatusTsMigrations
+
+	return VerifyStatus{
+		Status:   status,
+		Error:    errors.Join(errs...),
+		Findings: findings,
+	}
+}
+
+// verifyFindings walks the migration directory, and the registered go migrations, returning all the
+// issues found. Unlike collectMigrationsFS it does not stop at the first bad file.
+func (p *Provider) verifyFindings(fsys fs.FS, dir string) ([]VerifyFinding, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check to see if there are any template sql files, and if there are try and compile them.
-	// We are going to assume that vMigrations are less likely to have parsed errors in them.
-	// These should have been deployed.
-	var errs = make([]error, 0, len(tsMigrations))
-	for _, m := range vMigrations {
-		if getExtension(m.Source) != ".tpl.sql" {
+	var findings []VerifyFinding
+	byVersion := make(map[int64]Migrations)
+	seenRegistered := make(map[int64]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if entry.IsDir() || (ext != ".sql" && ext != ".go") || strings.HasSuffix(name, "_test.go") {
 			continue
 		}
-		if _, err := parseExecuteTplSql(p.baseFS, m.Source, p.packageName); err != nil {
-			status |= VerifyStatusTplSql
-			errs = append(errs, err)
+		source := path.Join(dir, name)
+		if isSQLCallback(name) {
+			content, err := fs.ReadFile(fsys, source)
+			if err != nil {
+				findings = append(findings, VerifyFinding{
+					File:     source,
+					Severity: VerifySeverityError,
+					Code:     VerifyCodeParse,
+					Message:  fmt.Sprintf("failed to read SQL callback file: %v", err),
+					Err:      err,
+				})
+				continue
+			}
+			findings = append(findings, p.verifySQLCallback(source, content)...)
+			continue
 		}
-	}
-	for _, m := range tsMigrations {
-		if getExtension(m.Source) != ".tpl.sql" {
+		if isRepeatable(name) {
+			findings = append(findings, p.verifyMigration(fsys, &Migration{Source: source, repeatable: true})...)
 			continue
 		}
-		if _, err := parseExecuteTplSql(p.baseFS, m.Source, p.packageName); err != nil {
-			status |= VerifyStatusTplSql
-			errs = append(errs, err)
+		v, err := NumericComponent(name)
+		if err != nil {
+			// every .sql file is expected to be a migration, .go files only if they start with a number
+			if ext == ".sql" || (name[0] >= '0' && name[0] <= '9') {
+				findings = append(findings, VerifyFinding{
+					File:     source,
+					Severity: VerifySeverityError,
+					Code:     VerifyCodeInvalidFilename,
+					Message:  fmt.Sprintf("not a valid migration file name: %v", err),
+					Err:      err,
+				})
+			}
+			continue
+		}
+		m := &Migration{Version: v, Next: -1, Previous: -1, Source: source}
+		if ext == ".sql" {
+			value, _, err := readAnnotation(fsys, source, requiresAnnotation)
+			if err == nil {
+				m.Requires, err = parseRequires(value)
+			}
+			if err != nil {
+				findings = append(findings, VerifyFinding{
+					File:     source,
+					Severity: VerifySeverityError,
+					Code:     VerifyCodeParse,
+					Message:  fmt.Sprintf("Requires annotation: %v", err),
+					Err:      err,
+				})
+			}
+		}
+		if ext == ".go" {
+			registered, ok := p.registeredGoMigrations[v]
+			switch {
+			case !ok:
+				findings = append(findings, VerifyFinding{
+					File:     source,
+					Severity: VerifySeverityWarning,
+					Code:     VerifyCodeUnregisteredGo,
+					Message:  "go migration file has no registered migration, it must be registered and built into a custom binary",
+				})
+			case filepath.Base(registered.Source) == name:
+				seenRegistered[v] = true
+				m = registered
+			}
+		}
+		byVersion[v] = append(byVersion[v], m)
+	}
+	for v, m := range p.registeredGoMigrations {
+		if !seenRegistered[v] {
+			byVersion[v] = append(byVersion[v], m)
 		}
 	}
 
-	return VerifyStatus{
-		Status: status,
-		Error:  errors.Join(errs...),
+	versions := make([]int64, 0, len(byVersion))
+	for v := range byVersion {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	findings = append(findings, verifyDependencies(byVersion, versions)...)
+
+	var lastSeq *Migration
+	for _, v := range versions {
+		ms := byVersion[v]
+		for _, dup := range ms[1:] {
+			findings = append(findings, VerifyFinding{
+				File:     dup.Source,
+				Severity: VerifySeverityError,
+				Code:     VerifyCodeDuplicateVersion,
+				Message:  fmt.Sprintf("version %d is also used by %s", v, ms[0].Source),
+			})
+		}
+		m := ms[0]
+		if m.IsTimestamp() {
+			findings = append(findings, VerifyFinding{
+				File:     m.Source,
+				Severity: VerifySeverityWarning,
+				Code:     VerifyCodeTimestampMigration,
+				Message:  "timestamp-based migration, run fix to make it sequential",
+			})
+		} else {
+			if lastSeq != nil && m.Version > lastSeq.Version+1 {
+				findings = append(findings, VerifyFinding{
+					File:     m.Source,
+					Severity: VerifySeverityWarning,
+					Code:     VerifyCodeVersionGap,
+					Message:  fmt.Sprintf("versions %d to %d are missing after %s", lastSeq.Version+1, m.Version-1, filepath.Base(lastSeq.Source)),
+				})
+			}
+			lastSeq = m
+		}
+		findings = append(findings, p.verifyMigration(fsys, m)...)
 	}
 
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+// verifyDependencies reports the migrations requiring versions there are no migrations for, and the
+// migrations requiring each other.
+func verifyDependencies(byVersion map[int64]Migrations, versions []int64) []VerifyFinding {
+	var findings []VerifyFinding
+	ms := make(Migrations, 0, len(versions))
+	for _, v := range versions {
+		m := byVersion[v][0]
+		for _, r := range m.Requires {
+			if _, ok := byVersion[r]; !ok {
+				findings = append(findings, VerifyFinding{
+					File:     m.Source,
+					Severity: VerifySeverityError,
+					Code:     VerifyCodeUnknownDependency,
+					Message:  fmt.Sprintf("requires version %d, there is no migration for it", r),
+				})
+			}
+		}
+		ms = append(ms, m)
+	}
+	// the unknown versions are already reported
+	_, err := topologicalOrder(ms, func(int64) bool { return true })
+	var derr ErrMigrationDependency
+	if !errors.As(err, &derr) {
+		return findings
+	}
+	for _, v := range derr.Cycle {
+		findings = append(findings, VerifyFinding{
+			File:     byVersion[v][0].Source,
+			Severity: VerifySeverityError,
+			Code:     VerifyCodeDependencyCycle,
+			Message:  fmt.Sprintf("part of a dependency cycle: %v", err),
+			Err:      err,
+		})
+	}
+	return findings
+}
+
+// verifyMigration checks that the migration can be loaded, and parsed for both directions.
+func (p *Provider) verifyMigration(fsys fs.FS, m *Migration) []VerifyFinding {
+	var content []byte
+	switch getExtension(m.Source) {
+	case ".go":
+		if m.Registered && m.UpFn == nil {
+			return []VerifyFinding{{
+				File:     m.Source,
+				Severity: VerifySeverityWarning,
+				Code:     VerifyCodeEmptyUp,
+				Message:  "go migration has no Up function",
+			}}
+		}
+		return nil
+	case ".tpl.sql":
+		buff, err := parseExecuteTplSql(fsys, m.Source, p.packageName)
+		if err != nil {
+			return []VerifyFinding{{
+				File:     m.Source,
+				Severity: VerifySeverityError,
+				Code:     VerifyCodeTemplate,
+				Message:  err.Error(),
+				Err:      err,
+			}}
+		}
+		content = buff.Bytes()
+	default:
+		var err error
+		if content, err = fs.ReadFile(fsys, m.Source); err != nil {
+			return []VerifyFinding{{
+				File:     m.Source,
+				Severity: VerifySeverityError,
+				Code:     VerifyCodeParse,
+				Message:  fmt.Sprintf("failed to read SQL migration file: %v", err),
+				Err:      err,
+			}}
+		}
+	}
+	return p.verifySQL(m.Source, content)
+}
+
+// verifySQL parses the sql for both directions, reporting parse errors and an empty Up section.
+func (p *Provider) verifySQL(source string, content []byte) []VerifyFinding {
+	var findings []VerifyFinding
+	var upErr string
+	for _, direction := range []bool{true, false} {
+		stmts, _, err := parseSQLMigration(p, bytes.NewReader(content), direction)
+		if err != nil {
+			// structural errors are reported the same for both directions, only report them once
+			if err.Error() == upErr {
+				continue
+			}
+			if direction {
+				upErr = err.Error()
+			}
+			f := VerifyFinding{
+				File:     source,
+				Severity: VerifySeverityError,
+				Code:     VerifyCodeParse,
+				Message:  fmt.Sprintf("%s: %v", directionOf(direction), err),
+				Err:      ErrMigrationSQLParse{Filename: filepath.Base(source), Up: direction, ErrUnwrap: ErrUnwrap{err}},
+			}
+			var lineErr ErrSQLParseLine
+			if errors.As(err, &lineErr) {
+				f.Line = lineErr.Line
+				f.Message = fmt.Sprintf("%s: %v", directionOf(direction), lineErr.Err)
+			}
+			findings = append(findings, f)
+			continue
+		}
+		if direction && len(stmts) == 0 {
+			findings = append(findings, VerifyFinding{
+				File:     source,
+				Severity: VerifySeverityWarning,
+				Code:     VerifyCodeEmptyUp,
+				Message:  "Up section has no statements",
+			})
+		}
+	}
+	return findings
+}
+
+// verifySQLCallback parses the SQL callback for both directions, unlike a migration a callback
+// may have no statements.
+func (p *Provider) verifySQLCallback(source string, content []byte) []VerifyFinding {
+	var findings []VerifyFinding
+	for _, f := range p.verifySQL(source, content) {
+		if f.Code != VerifyCodeEmptyUp {
+			findings = append(findings, f)
+		}
+	}
+	return findings
 }
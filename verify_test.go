@@ -0,0 +1,61 @@
+package goose
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestVerifyFindings(t *testing.T) {
+	t.Parallel()
+
+	const good = "-- +goose Up\nSELECT 1;\n-- +goose Down\nSELECT 2;\n"
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql":          {Data: []byte(good)},
+		"migrations/00001_dup.sql":        {Data: []byte(good)},
+		"migrations/00003_gap.sql":        {Data: []byte(good)},
+		"migrations/00004_bad.sql":        {Data: []byte("-- +goose Up\nSELECT 1;\n-- +goose Down\n-- +goose StatementBegin\nSELECT 2;\n")},
+		"migrations/00005_empty.sql":      {Data: []byte("-- +goose Up\n-- +goose Down\nSELECT 2;\n")},
+		"migrations/00006_go.go":          {Data: []byte("package migrations\n")},
+		"migrations/20220101000000_a.sql": {Data: []byte(good)},
+		"migrations/bad-name.sql":         {Data: []byte(good)},
+		"migrations/helpers.go":           {Data: []byte("package migrations\n")},
+	}
+	p := NewProvider(Filesystem(fsys))
+	status := p.Verify("migrations")
+
+	type key struct {
+		file string
+		code VerifyCode
+		line int
+	}
+	expected := map[key]VerifySeverity{
+		{"migrations/00001_dup.sql", VerifyCodeDuplicateVersion, 0}:          VerifySeverityError,
+		{"migrations/00003_gap.sql", VerifyCodeVersionGap, 0}:                VerifySeverityWarning,
+		{"migrations/00004_bad.sql", VerifyCodeParse, 4}:                     VerifySeverityError,
+		{"migrations/00005_empty.sql", VerifyCodeEmptyUp, 0}:                 VerifySeverityWarning,
+		{"migrations/00006_go.go", VerifyCodeUnregisteredGo, 0}:              VerifySeverityWarning,
+		{"migrations/20220101000000_a.sql", VerifyCodeTimestampMigration, 0}: VerifySeverityWarning,
+		{"migrations/bad-name.sql", VerifyCodeInvalidFilename, 0}:            VerifySeverityError,
+	}
+	for _, f := range status.Findings {
+		k := key{f.File, f.Code, f.Line}
+		severity, ok := expected[k]
+		if !ok {
+			t.Errorf("unexpected finding %v", f)
+			continue
+		}
+		if severity != f.Severity {
+			t.Errorf("finding %v severity, got %v expected %v", k, f.Severity, severity)
+		}
+		delete(expected, k)
+	}
+	for k := range expected {
+		t.Errorf("missing finding %v", k)
+	}
+	if !status.HasTsMigrations() {
+		t.Errorf("expected status to have timestamp migrations")
+	}
+	if status.Status&VerifyStatusErr != VerifyStatusErr {
+		t.Errorf("expected status to have the error bit set, got %v", status.Status)
+	}
+}
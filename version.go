@@ -2,6 +2,7 @@ package goose
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 )
 
@@ -13,6 +14,10 @@ func Version(db *sql.DB, dir string, opts ...OptionsFunc) error {
 // Version prints the current version of the database.
 func (p *Provider) Version(db *sql.DB, dir string, opts ...OptionsFunc) error {
 	migrationVersion, dbVersion, err := p.GetVersions(db, dir, opts...)
+	if errors.As(err, &ErrNotInitialized{}) {
+		p.log.Printf("goose: version: not initialized\n")
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -29,12 +34,14 @@ func (p *Provider) Version(db *sql.DB, dir string, opts ...OptionsFunc) error {
 // GetVersion will return the current version of the migration, and database version, or -1, -1 if not
 // found or if there is an error
 // If db is nil, or the option.noVersioning is specificed, then the dbVersion will be -1.
+// If WithReadOnly is given the version table is not created, an ErrNotInitialized is returned if it does
+// not exist.
 func (p *Provider) GetVersions(db *sql.DB, dir string, opts ...OptionsFunc) (migrationVersion int64, dbVersion int64, err error) {
 	if p == nil {
 		return -1, -1, nil
 	}
 	var (
-		option = applyOptions(opts)
+		option = p.applyOptions(opts)
 	)
 	migrationVersion, dbVersion = -1, -1
 	migrations, err := p.CollectMigrations(dir, minVersion, maxVersion)
@@ -47,8 +54,10 @@ func (p *Provider) GetVersions(db *sql.DB, dir string, opts ...OptionsFunc) (mig
 	if option.noVersioning {
 		return migrationVersion, dbVersion, nil
 	}
-	dbVersion, err = p.GetDBVersion(db)
-	return migrationVersion, dbVersion, err
+	if dbVersion, err = p.dbVersion(db, option.readOnly); err != nil {
+		return migrationVersion, -1, err
+	}
+	return migrationVersion, dbVersion, nil
 }
 
 // TableName returns goose db version table name
@@ -71,3 +80,26 @@ func (p *Provider) SetTableName(n string) {
 	p.tableName = n
 	p.dialect.SetTableName(n)
 }
+
+// SetTableSchema set the schema of the goose db version table, see TableSchema
+func SetTableSchema(schema string, create bool) {
+	defaultProvider.SetTableSchema(schema, create)
+}
+
+// SetTableSchema set the schema of the goose db version table, see TableSchema
+func (p *Provider) SetTableSchema(schema string, create bool) {
+	p.tableSchema = schema
+	p.createSchema = create
+	p.dialect.SetSchema(schema, create)
+}
+
+// SetQuoteIdentifiers sets if the names of the schema and the goose db version table are quoted, see QuoteIdentifiers
+func SetQuoteIdentifiers(quote bool) {
+	defaultProvider.SetQuoteIdentifiers(quote)
+}
+
+// SetQuoteIdentifiers sets if the names of the schema and the goose db version table are quoted, see QuoteIdentifiers
+func (p *Provider) SetQuoteIdentifiers(quote bool) {
+	p.quoteIdentifiers = quote
+	setQuoteIdentifiers(p.dialect, quote)
+}
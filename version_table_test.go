@@ -0,0 +1,107 @@
+package goose
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestVersionTableQuoting(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		dialect, schema, table string
+		quote                  bool
+		expected               string
+	}{
+		// by default the names are used as they are written, as the database folds them
+		{DialectPostgres, "", "goose_db_version", false, `goose_db_version`},
+		{DialectPostgres, "", "MyTable", false, `MyTable`},
+		{DialectPostgres, "", "app.goose_db_version", false, `app.goose_db_version`},
+		// names written quoted are not quoted again
+		{DialectPostgres, "", `"MyTable"`, false, `"MyTable"`},
+		{DialectPostgres, "", `"My.App"."MyTable"`, false, `"My.App"."MyTable"`},
+		{DialectPostgres, "", `"MyTable"`, true, `"MyTable"`},
+		{DialectMSSQL, "", "[dbo].[goose_db_version]", false, "[dbo].[goose_db_version]"},
+		{DialectPostgres, "", "goose_db_version", true, `"goose_db_version"`},
+		{DialectPostgres, "App", "Versions", true, `"App"."Versions"`},
+		{DialectPostgres, "", "app.goose_db_version", true, `"app"."goose_db_version"`},
+		{DialectMySQL, "app", "order", true, "`app`.`order`"},
+		{DialectClickHouse, "", "we`ird", true, "`we``ird`"},
+		{DialectMSSQL, "dbo", "goose_db_version", true, "[dbo].[goose_db_version]"},
+	}
+	for _, tc := range tests {
+		p := NewProvider(Dialect(tc.dialect), Tablename(tc.table), TableSchema(tc.schema, false), QuoteIdentifiers(tc.quote))
+		expected := "INSERT INTO " + tc.expected + " ("
+		if got := p.dialect.InsertVersionSQL(); len(got) < len(expected) || got[:len(expected)] != expected {
+			t.Errorf("%s %q %q, got %q expected it to start with %q", tc.dialect, tc.schema, tc.table, got, expected)
+		}
+	}
+
+	// the suffix of the tables next to the version table is inside the quotes of a quoted name
+	p := NewProvider(Dialect(DialectPostgres), Tablename(`"MyTable"`))
+	if got, expected := p.dialect.InsertRepeatableSQL(), `INSERT INTO "MyTable_repeatable" (`; len(got) < len(expected) || got[:len(expected)] != expected {
+		t.Errorf("repeatable, got %q expected it to start with %q", got, expected)
+	}
+
+	p = NewProvider(TableSchema("app", true), Dialect(DialectPostgres))
+	if got, expected := p.dialect.CreateSchemaSQL(), `CREATE SCHEMA IF NOT EXISTS app;`; got != expected {
+		t.Errorf("create schema, got %q expected %q", got, expected)
+	}
+	p.SetQuoteIdentifiers(true)
+	if got, expected := p.dialect.CreateSchemaSQL(), `CREATE SCHEMA IF NOT EXISTS "app";`; got != expected {
+		t.Errorf("create schema, got %q expected %q", got, expected)
+	}
+	p.SetTableSchema("app", false)
+	if got := p.dialect.CreateSchemaSQL(); got != "" {
+		t.Errorf("create schema, got %q expected no SQL", got)
+	}
+}
+
+func TestVersionTableSchema(t *testing.T) {
+	t.Parallel()
+	db := openSQLite(t)
+	fsys := fstest.MapFS{
+		"00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"00002_b.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+		"00003_c.sql": {Data: []byte("-- +goose Up\nCREATE TABLE c (id INTEGER);\n-- +goose Down\nDROP TABLE c;\n")},
+	}
+	// a reserved word, in the schema of the main database
+	newProvider := func(fsys fstest.MapFS) *Provider {
+		return NewProvider(Filesystem(fsys), Dialect(DialectSQLite3), Tablename("Order"), TableSchema("main", true), QuoteIdentifiers(true))
+	}
+	p := newProvider(fsys)
+	if err := newProvider(fstest.MapFS{"00001_a.sql": fsys["00001_a.sql"], "00003_c.sql": fsys["00003_c.sql"]}).
+		Up(db, ".", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	// 00002 is missing, with a version before the applied 00003 it is only applied when allowed
+	if err := p.Up(db, ".", WithNoOutput()); err == nil {
+		t.Fatal("up with a missing migration, got nil expected an error")
+	}
+	if err := p.Up(db, ".", WithNoOutput(), WithAllowMissing()); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM "Order" WHERE version_id > 0`).Scan(&count); err != nil || count != 3 {
+		t.Errorf("versions, got %d, %v expected 3", count, err)
+	}
+
+	events := make(chan Eventer, 10)
+	if err := p.Status(db, ".", WithNoOutput(), WithEvents(events, false)); err != nil {
+		t.Fatal(err)
+	}
+	applied := 0
+	for e := range events {
+		if se, ok := e.(StatusEvent); ok && !se.AppliedAt.IsZero() {
+			applied++
+		}
+	}
+	if applied != 3 {
+		t.Errorf("status, got %d applied expected 3", applied)
+	}
+	if err := p.Reset(db, ".", WithNoOutput()); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := p.GetDBVersion(db); err != nil || version != 0 {
+		t.Errorf("version after reset, got %d, %v expected 0", version, err)
+	}
+}